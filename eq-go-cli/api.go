@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	eqgo "github.com/kevinmbeaulieu/eq-go/eq-go"
+)
+
+// Usage: `go run path/to/eq-go-cli api [--fail-on-breaking] --pkgs foo,bar --paths path/to/package/foo,path/to/package/bar`
+//
+// Compare only the exported API surfaces of two packages, classifying each difference as
+// backward-compatible or breaking.
+func apiCommand(args []string) {
+	flags := flag.NewFlagSet("api", flag.ExitOnError)
+
+	var pkgNamesArg stringSliceArg
+	flags.Var(&pkgNamesArg, "pkgs", "Comma-separated pair of input packages' names, for display (default: the names in the sources)")
+
+	var pkgPathsArg stringSliceArg
+	flags.Var(&pkgPathsArg, "paths", "Comma-separated pair of input packages' paths")
+
+	var failOnBreaking bool
+	flags.BoolVar(&failOnBreaking, "fail-on-breaking", false, "Exit with a non-zero status if any change is not backward-compatible")
+
+	flags.Parse(args)
+
+	if len(pkgPathsArg) != 2 {
+		exitWithError(fmt.Errorf("--paths requires two packages' paths"))
+	}
+	lhsPkgPath := pkgPathsArg[0]
+	rhsPkgPath := pkgPathsArg[1]
+
+	lhsPkg, lhsFSet := loadPackage(lhsPkgPath)
+	rhsPkg, rhsFSet := loadPackage(rhsPkgPath)
+
+	lhsPkgName := packageName(pkgNamesArg, 0, lhsPkg)
+	rhsPkgName := packageName(pkgNamesArg, 1, rhsPkg)
+
+	result, err := eqgo.CompareAPI(lhsPkg, lhsFSet, rhsPkg, rhsFSet)
+	if err != nil {
+		exitWithError(err)
+	}
+	if result.Equivalent {
+		fmt.Printf("%s (%s) and %s (%s) export equivalent APIs.\n", lhsPkgName, lhsPkgPath, rhsPkgName, rhsPkgPath)
+	} else {
+		fmt.Printf("%s (%s) and %s (%s) export different APIs.\n\n%s\n", lhsPkgName, lhsPkgPath, rhsPkgName, rhsPkgPath, result.Format())
+	}
+
+	if failOnBreaking && !result.Compatible {
+		os.Exit(1)
+	}
+}
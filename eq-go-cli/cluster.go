@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	eqgo "github.com/kevinmbeaulieu/eq-go/eq-go"
+)
+
+// Usage: `go run path/to/eq-go-cli cluster --pkgs foo,bar,baz --paths path/to/foo,path/to/bar,path/to/baz`
+//
+// Partition any number of packages into classes of equivalent packages.
+func clusterCommand(args []string) {
+	flags := flag.NewFlagSet("cluster", flag.ExitOnError)
+
+	var pkgNamesArg stringSliceArg
+	flags.Var(&pkgNamesArg, "pkgs", "Comma-separated list of input packages' names, for display (default: the names in the sources)")
+
+	var pkgPathsArg stringSliceArg
+	flags.Var(&pkgPathsArg, "paths", "Comma-separated list of input packages' paths")
+
+	var opts eqgo.Options
+	addOptionsFlags(flags, &opts)
+
+	flags.Parse(args)
+
+	if len(pkgNamesArg) > 0 && len(pkgNamesArg) != len(pkgPathsArg) {
+		exitWithError(fmt.Errorf("got %d package names but %d paths", len(pkgNamesArg), len(pkgPathsArg)))
+	}
+
+	var pkgs [][]*ast.File
+	var fsets []*token.FileSet
+	for i := range pkgPathsArg {
+		pkg, fset := loadPackage(pkgPathsArg[i])
+		pkgs = append(pkgs, pkg)
+		fsets = append(fsets, fset)
+	}
+
+	result, err := eqgo.ClusterPackages(pkgs, fsets, &opts)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	describe := func(i int) string {
+		return fmt.Sprintf("%s (%s)", packageName(pkgNamesArg, i, pkgs[i]), pkgPathsArg[i])
+	}
+
+	fmt.Printf("%d equivalence classes:\n", len(result.Classes))
+	for c, class := range result.Classes {
+		var members []string
+		for _, i := range class {
+			members = append(members, describe(i))
+		}
+		fmt.Printf("    %d: %s\n", c+1, strings.Join(members, ", "))
+	}
+
+	if len(result.Differences) > 0 {
+		fmt.Printf("\nDifferences between representatives:\n")
+	}
+	for _, d := range result.Differences {
+		fmt.Printf("    %d (%s) and %d (%s):\n", d.A+1, describe(result.Classes[d.A][0]), d.B+1, describe(result.Classes[d.B][0]))
+		for _, key := range d.Declarations {
+			fmt.Printf("        %s\n", key)
+		}
+	}
+}
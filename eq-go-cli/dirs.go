@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	eqgo "github.com/kevinmbeaulieu/eq-go/eq-go"
+)
+
+// Usage: `go run path/to/eq-go-cli dirs path/to/left/root path/to/right/root`
+//
+// Compare every package found in two directory trees, paired by relative path.
+func dirsCommand(args []string) {
+	flags := flag.NewFlagSet("dirs", flag.ExitOnError)
+
+	var opts eqgo.Options
+	addOptionsFlags(flags, &opts)
+	flags.BoolVar(&opts.IncludeTests, "tests", false, "Also compare test files and external test packages")
+	flags.BoolVar(&opts.InitOrder, "init-order", false, "Also require side-effecting initializers and init functions to run in the same order")
+
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		exitWithError(fmt.Errorf("dirs requires the left and right roots"))
+	}
+
+	lhsRoot := flags.Arg(0)
+	rhsRoot := flags.Arg(1)
+
+	result, err := eqgo.CompareDirs(lhsRoot, rhsRoot, &opts)
+	if err != nil {
+		exitWithError(err)
+	}
+	if result.Equivalent {
+		fmt.Printf("%s and %s are equivalent (%d packages).\n", lhsRoot, rhsRoot, len(result.Packages))
+	} else {
+		fmt.Printf("%s and %s are not equivalent.\n\n%s\n", lhsRoot, rhsRoot, result.Format(nil))
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+
+	eqgo "github.com/kevinmbeaulieu/eq-go/eq-go"
+)
+
+// Usage: `go run path/to/eq-go-cli fmt [-l|-check] path/to/file.go path/to/package ...`
+//
+// Print each file, or each package merged into a single file, in the canonical form used for
+// comparisons. With -l, instead list the files which are not already in canonical form. -check
+// does the same, and also exits with a non-zero status if any are listed.
+func fmtCommand(args []string) {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+
+	var list, check bool
+	flags.BoolVar(&list, "l", false, "List files which are not in canonical form instead of printing them")
+	flags.BoolVar(&check, "check", false, "Like -l, but exit with a non-zero status if any file is not in canonical form")
+
+	flags.Parse(args)
+
+	listed := false
+	for _, path := range flags.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		var pkg []*ast.File
+		var filenames []string
+		if info.IsDir() {
+			var fset *token.FileSet
+			pkg, fset = loadPackage(path)
+			for _, f := range pkg {
+				filenames = append(filenames, fset.Position(f.Pos()).Filename)
+			}
+		} else {
+			filenames = []string{path}
+		}
+
+		if list || check {
+			for _, filename := range filenames {
+				if !isCanonical(filename) {
+					fmt.Println(filename)
+					listed = true
+				}
+			}
+			continue
+		}
+
+		var f *ast.File
+		if info.IsDir() {
+			if f, err = eqgo.CanonicalizePackage(pkg); err != nil {
+				exitWithError(err)
+			}
+		} else {
+			f = parseFile(token.NewFileSet(), path)
+		}
+
+		out, err := eqgo.FormatCanonical(f)
+		if err != nil {
+			exitWithError(err)
+		}
+		os.Stdout.Write(out)
+	}
+
+	if check && listed {
+		os.Exit(1)
+	}
+}
+
+// Whether a file's contents are exactly its canonical form.
+func isCanonical(filename string) bool {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	out, err := eqgo.FormatCanonical(parseFile(token.NewFileSet(), filename))
+	if err != nil {
+		exitWithError(err)
+	}
+	return bytes.Equal(src, out)
+}
+
+func parseFile(fset *token.FileSet, filename string) *ast.File {
+	f, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+	if err != nil {
+		exitWithError(err)
+	}
+	return f
+}
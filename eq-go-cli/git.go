@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kevinmbeaulieu/eq-go/eq-go/loader"
+)
+
+// Helpers to read packages from git revisions with the local git binary, without touching the
+// working tree.
+
+// Load the packages in the directory at dir, relative to the working directory, as of the git
+// revision rev of the repository containing the working directory. Returns the packages along with
+// a description of where they came from, e.g. "HEAD:gen/api". If rev is empty, the packages are
+// loaded from the working tree instead.
+func loadRevisionPackages(rev string, dir string) (*loader.Packages, string) {
+	if rev == "" {
+		return loadPackages(dir), dir
+	}
+
+	prefix, err := git("rev-parse", "--show-prefix")
+	if err != nil {
+		exitWithError(err)
+	}
+	repoDir := path.Join(strings.TrimSpace(string(prefix)), filepath.ToSlash(dir))
+	if repoDir == ".." || strings.HasPrefix(repoDir, "../") {
+		exitWithError(fmt.Errorf("%s is outside the repository", dir))
+	}
+
+	files, err := readRevisionFiles(rev, repoDir)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	name := rev + ":" + repoDir
+	p, err := loader.LoadFiles(name, files)
+	if err != nil {
+		exitWithError(err)
+	}
+	if len(p.Files) == 0 {
+		exitWithError(fmt.Errorf("%s: no non-test Go files", name))
+	}
+	return p, name
+}
+
+// Read the files directly in the directory at repoDir, relative to the root of the repository, as
+// of rev, keyed by base name.
+func readRevisionFiles(rev string, repoDir string) (map[string][]byte, error) {
+	args := []string{"ls-tree", "-z", "--full-tree", rev}
+	if repoDir != "." {
+		args = append(args, "--", repoDir+"/")
+	}
+	tree, err := git(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, entry := range strings.Split(string(tree), "\x00") {
+		// Each entry is "<mode> <type> <object>\t<path>".
+		tab := strings.Index(entry, "\t")
+		if tab < 0 {
+			continue
+		}
+		fields := strings.Fields(entry[:tab])
+		if len(fields) != 3 || fields[0] == "120000" || fields[1] != "blob" {
+			continue
+		}
+
+		src, err := git("cat-file", "blob", fields[2])
+		if err != nil {
+			return nil, err
+		}
+		files[path.Base(entry[tab+1:])] = src
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s:%s: no files", rev, repoDir)
+	}
+	return files, nil
+}
+
+// Run git with args and return its output.
+func git(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
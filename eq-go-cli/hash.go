@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/token"
+	"os"
+	"sort"
+
+	eqgo "github.com/kevinmbeaulieu/eq-go/eq-go"
+)
+
+// Usage: `go run path/to/eq-go-cli hash [-decls] path/to/file.go path/to/package ...`
+//
+// Print a fingerprint of each file, or of each package as a whole, which is identical for all
+// equivalent inputs. With -decls, also print a fingerprint of each top-level declaration.
+func hashCommand(args []string) {
+	flags := flag.NewFlagSet("hash", flag.ExitOnError)
+
+	var decls bool
+	flags.BoolVar(&decls, "decls", false, "Also print a fingerprint of each top-level declaration")
+
+	flags.Parse(args)
+
+	for _, path := range flags.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		var fp *eqgo.Fingerprints
+		if info.IsDir() {
+			pkg, _ := loadPackage(path)
+			fp, err = eqgo.FingerprintPackage(pkg)
+		} else {
+			fp, err = eqgo.Fingerprint(parseFile(token.NewFileSet(), path))
+		}
+		if err != nil {
+			exitWithError(err)
+		}
+
+		fmt.Printf("%s  %s\n", fp.Digest, path)
+		if !decls {
+			continue
+		}
+
+		var keys []string
+		for k := range fp.Declarations {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("    %s  %s\n", fp.Declarations[k], k)
+		}
+	}
+}
@@ -4,13 +4,12 @@ import (
 	"flag"
 	"fmt"
 	"go/ast"
-	"go/parser"
 	"go/token"
-	"io/ioutil"
-	"path/filepath"
+	"os"
 	"strings"
 
 	eqgo "github.com/kevinmbeaulieu/eq-go/eq-go"
+	"github.com/kevinmbeaulieu/eq-go/eq-go/loader"
 )
 
 type stringSliceArg []string
@@ -28,58 +27,228 @@ func (a *stringSliceArg) Set(s string) error {
 	return nil
 }
 
-// Usage: `go run path/to/eq-go-cli --pkgs foo,bar --paths path/to/package/foo,path/to/package/bar`
+// Usage:
+//
+//	`go run path/to/eq-go-cli [api|cluster|threeway|fmt|hash|snapshot|dirs|verify] --pkgs foo,bar --paths path/to/package/foo,path/to/package/bar`
+//	`go run path/to/eq-go-cli --left-rev main --right-rev HEAD --path path/to/package`
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "api":
+			apiCommand(os.Args[2:])
+			return
+		case "cluster":
+			clusterCommand(os.Args[2:])
+			return
+		case "threeway":
+			threeWayCommand(os.Args[2:])
+			return
+		case "fmt":
+			fmtCommand(os.Args[2:])
+			return
+		case "hash":
+			hashCommand(os.Args[2:])
+			return
+		case "snapshot":
+			snapshotCommand(os.Args[2:])
+			return
+		case "dirs":
+			dirsCommand(os.Args[2:])
+			return
+		case "verify":
+			verifyCommand(os.Args[2:])
+			return
+		}
+	}
+
 	var pkgNamesArg stringSliceArg
-	flag.Var(&pkgNamesArg, "pkgs", "Comma-separated pair of input packages' names")
+	flag.Var(&pkgNamesArg, "pkgs", "Comma-separated pair of input packages' names, for display (default: the names in the sources)")
 
 	var pkgPathsArg stringSliceArg
 	flag.Var(&pkgPathsArg, "paths", "Comma-separated pair of input packages' paths")
 
+	var leftRev, rightRev, revPath string
+	flag.StringVar(&leftRev, "left-rev", "", "Git revision to read the first package from, at --path (default: the working tree)")
+	flag.StringVar(&rightRev, "right-rev", "", "Git revision to read the second package from, at --path (default: the working tree)")
+	flag.StringVar(&revPath, "path", "", "Path of the package to compare between --left-rev and --right-rev")
+
+	var opts eqgo.Options
+	addOptionsFlags(flag.CommandLine, &opts)
+	flag.BoolVar(&opts.Subset, "subset", false, "Only require every declaration of the first package to have an equivalent in the second")
+	flag.BoolVar(&opts.IncludeTests, "tests", false, "Also compare test files and external test packages")
+	flag.BoolVar(&opts.InitOrder, "init-order", false, "Also require side-effecting initializers and init functions to run in the same order")
+	flag.BoolVar(&opts.ReportLayout, "report-layout", false, "Also report declarations which moved between files")
+
+	var buildConfigsArg stringSliceArg
+	flag.Var(&buildConfigsArg, "configs", "Comma-separated build configurations to compare under, each GOOS/GOARCH optionally followed by :tag1+tag2")
+
 	flag.Parse()
 
-	lhsPkgName := pkgNamesArg[0]
-	rhsPkgName := pkgNamesArg[1]
+	var lhs, rhs *loader.Packages
+	var lhsPkgPath, rhsPkgPath string
+	if leftRev != "" || rightRev != "" {
+		if revPath == "" || len(pkgPathsArg) > 0 || len(buildConfigsArg) > 0 {
+			exitWithError(fmt.Errorf("--left-rev and --right-rev require --path, and can't be used with --paths or --configs"))
+		}
+		lhs, lhsPkgPath = loadRevisionPackages(leftRev, revPath)
+		rhs, rhsPkgPath = loadRevisionPackages(rightRev, revPath)
+	} else {
+		if len(pkgPathsArg) != 2 {
+			exitWithError(fmt.Errorf("--paths requires two packages' paths"))
+		}
+		lhsPkgPath = pkgPathsArg[0]
+		rhsPkgPath = pkgPathsArg[1]
 
-	lhsPkgPath := pkgPathsArg[0]
-	rhsPkgPath := pkgPathsArg[1]
+		if len(buildConfigsArg) > 0 {
+			compareBuildConfigs(lhsPkgPath, rhsPkgPath, buildConfigsArg, &opts)
+			return
+		}
+
+		lhs = loadPackages(lhsPkgPath)
+		rhs = loadPackages(rhsPkgPath)
+	}
 
-	lhsPkg, lhsFSet := loadPackage(lhsPkgName, lhsPkgPath)
-	rhsPkg, rhsFSet := loadPackage(rhsPkgName, rhsPkgPath)
+	lhsPkgName := packageName(pkgNamesArg, 0, lhs.Files)
+	rhsPkgName := packageName(pkgNamesArg, 1, rhs.Files)
 
-	eq, msg := eqgo.PackagesEquivalent(lhsPkg, lhsFSet, rhsPkg, rhsFSet, nil)
-	if eq {
+	result, err := eqgo.CompareLoadedPackages(lhs, rhs, &opts)
+	if err != nil {
+		exitWithError(err)
+	}
+	if opts.Subset {
+		if result.Equivalent {
+			fmt.Printf("%s (%s) is contained in %s (%s).\n", lhsPkgName, lhsPkgPath, rhsPkgName, rhsPkgPath)
+		} else {
+			fmt.Printf("%s (%s) is not contained in %s (%s).\n\n%s\n", lhsPkgName, lhsPkgPath, rhsPkgName, rhsPkgPath, result.Format(nil))
+		}
+		if len(result.Additions) > 0 {
+			fmt.Printf("\n%s\n", formatAdditions(result))
+		}
+	} else if result.Equivalent {
 		fmt.Printf("%s (%s) and %s (%s) are equivalent.\n", lhsPkgName, lhsPkgPath, rhsPkgName, rhsPkgPath)
 	} else {
-		fmt.Printf("%s (%s) and %s (%s) are not equivalent.\n\n%s\n", lhsPkgName, lhsPkgPath, rhsPkgName, rhsPkgPath, msg)
+		fmt.Printf("%s (%s) and %s (%s) are not equivalent.\n\n%s\n", lhsPkgName, lhsPkgPath, rhsPkgName, rhsPkgPath, result.Format(nil))
+		fmt.Printf("\n%s\n", formatSimilarity(result))
+	}
+	if result.Layout != nil {
+		fmt.Printf("\n%s\n", result.Layout.Format())
 	}
 }
 
-func loadPackage(name string, path string) (*ast.Package, *token.FileSet) {
-	pkg := ast.Package{
-		Name:  name,
-		Files: make(map[string]*ast.File),
+// Compare two package directories once per build configuration.
+func compareBuildConfigs(lhsPkgPath string, rhsPkgPath string, buildConfigsArg stringSliceArg, opts *eqgo.Options) {
+	var configs []loader.Config
+	for _, s := range buildConfigsArg {
+		config, err := parseBuildConfig(s)
+		if err != nil {
+			exitWithError(err)
+		}
+		configs = append(configs, config)
 	}
 
-	files, err := ioutil.ReadDir(path)
+	result, err := eqgo.CompareBuildConfigs(lhsPkgPath, rhsPkgPath, configs, opts)
 	if err != nil {
-		panic(err)
+		exitWithError(err)
 	}
 
-	fset := token.NewFileSet()
-	for _, f := range files {
-		if !strings.HasSuffix(f.Name(), ".go") {
-			continue
+	for _, c := range result.Configs {
+		switch {
+		case c.Equivalent():
+			fmt.Printf("%s: equivalent\n", c.Config)
+		case c.LeftMissing:
+			fmt.Printf("%s: not equivalent: no Go files in %s\n", c.Config, lhsPkgPath)
+		case c.RightMissing:
+			fmt.Printf("%s: not equivalent: no Go files in %s\n", c.Config, rhsPkgPath)
+		default:
+			fmt.Printf("%s: %s\n", c.Config, c.Result.Format(nil))
 		}
+	}
 
-		filename := filepath.Join(path, f.Name())
-		src, err := parser.ParseFile(fset, filename, nil, parser.AllErrors)
-		if err != nil {
-			panic(err)
+	if result.Equivalent {
+		fmt.Printf("\n%s and %s are equivalent under all %d configurations.\n", lhsPkgPath, rhsPkgPath, len(configs))
+	} else {
+		fmt.Printf("\n%s and %s diverge under %d of %d configurations.\n", lhsPkgPath, rhsPkgPath, len(result.Diverging()), len(configs))
+	}
+}
+
+// Parse a build configuration of the form GOOS/GOARCH, optionally followed by :tag1+tag2.
+func parseBuildConfig(s string) (loader.Config, error) {
+	var config loader.Config
+
+	platform := s
+	if i := strings.Index(s, ":"); i >= 0 {
+		platform = s[:i]
+		config.Tags = strings.Split(s[i+1:], "+")
+	}
+
+	parts := strings.Split(platform, "/")
+	if len(parts) != 2 {
+		return config, fmt.Errorf("invalid build configuration %q, want GOOS/GOARCH[:tag1+tag2]", s)
+	}
+	config.GOOS, config.GOARCH = parts[0], parts[1]
+	return config, nil
+}
+
+// Register flags for the normalizations shared by all comparison modes.
+func addOptionsFlags(flags *flag.FlagSet, opts *eqgo.Options) {
+	flags.BoolVar(&opts.FoldConstants, "fold-constants", false, "Compare literals and constant expressions by value")
+	flags.BoolVar(&opts.NormalizeParentheses, "normalize-parens", false, "Ignore parentheses which don't affect parsing")
+	flags.BoolVar(&opts.FlattenDeclarations, "flatten", false, "Ignore grouping of declarations, fields and parameters")
+	flags.BoolVar(&opts.UnorderedInterfaceMethods, "unordered-interfaces", false, "Ignore the order of interface methods")
+	flags.BoolVar(&opts.ExpandEmbeddedInterfaces, "expand-embedded", false, "Expand embedded interfaces declared in the same package (requires --unordered-interfaces)")
+	flags.BoolVar(&opts.TypeCheck, "typecheck", false, "Type-check the packages and compare resolved objects, types and constant values")
+}
+
+func formatSimilarity(result *eqgo.Result) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%.1f%% equivalent", result.Similarity*100)
+	for _, d := range result.Declarations {
+		if d.Similarity < 1 {
+			fmt.Fprintf(&builder, "\n    %5.1f%%  %s", d.Similarity*100, d.Key)
 		}
+	}
+	return builder.String()
+}
 
-		pkg.Files[filename] = src
+func formatAdditions(result *eqgo.Result) string {
+	var builder strings.Builder
+	fmt.Fprint(&builder, "additions:")
+	for _, d := range result.Additions {
+		fmt.Fprintf(&builder, "\n    %s (%v)", d.Key, d.RightPos)
 	}
+	return builder.String()
+}
+
+// Load the library package in the directory at path for the host platform, exiting if it can't be
+// loaded.
+func loadPackage(path string) ([]*ast.File, *token.FileSet) {
+	p := loadPackages(path)
+	return p.Files, p.Fset
+}
+
+// Load the packages in the directory at path for the host platform, as for loadPackage, along with
+// any test packages.
+func loadPackages(path string) *loader.Packages {
+	p, err := loader.Load(path)
+	if err != nil {
+		exitWithError(err)
+	}
+	if len(p.Files) == 0 {
+		exitWithError(fmt.Errorf("%s: no non-test Go files", path))
+	}
+	return p
+}
+
+// Name to display for the i'th package: the name given on the command line, if any, or else the
+// name in its sources, given as its files.
+func packageName(names stringSliceArg, i int, files []*ast.File) string {
+	if i < len(names) {
+		return names[i]
+	}
+	return files[0].Name.Name
+}
 
-	return &pkg, fset
+func exitWithError(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
 }
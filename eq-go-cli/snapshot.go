@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	eqgo "github.com/kevinmbeaulieu/eq-go/eq-go"
+)
+
+// Usage:
+//
+//	`go run path/to/eq-go-cli snapshot path/to/package > baseline.json`
+//	`go run path/to/eq-go-cli snapshot --compare baseline.json path/to/package`
+//
+// Record the canonical form of a package as a JSON snapshot, or compare a package against a
+// snapshot recorded earlier.
+func snapshotCommand(args []string) {
+	flags := flag.NewFlagSet("snapshot", flag.ExitOnError)
+
+	var snapshotPath string
+	flags.StringVar(&snapshotPath, "compare", "", "Compare the package against this snapshot instead of recording one")
+
+	var opts eqgo.Options
+	addOptionsFlags(flags, &opts)
+
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		exitWithError(fmt.Errorf("snapshot requires a single package's path"))
+	}
+
+	pkgPath := flags.Arg(0)
+	pkg, fset := loadPackage(pkgPath)
+
+	if snapshotPath == "" {
+		snapshot, err := eqgo.TakePackageSnapshot(pkg, fset)
+		if err != nil {
+			exitWithError(err)
+		}
+		if err := eqgo.WriteSnapshot(os.Stdout, snapshot); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+
+	file, err := os.Open(snapshotPath)
+	if err != nil {
+		exitWithError(err)
+	}
+	defer file.Close()
+
+	snapshot, err := eqgo.ReadSnapshot(file)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	result, err := eqgo.CompareSnapshot(snapshot, pkg, fset, &opts)
+	if err != nil {
+		exitWithError(err)
+	}
+	if result.Equivalent {
+		fmt.Printf("%s is equivalent to snapshot %s.\n", pkgPath, snapshotPath)
+	} else {
+		fmt.Printf("%s is not equivalent to snapshot %s.\n\n%s\n", pkgPath, snapshotPath, result.Format(nil))
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	eqgo "github.com/kevinmbeaulieu/eq-go/eq-go"
+)
+
+// Usage: `go run path/to/eq-go-cli threeway --paths path/to/base,path/to/left,path/to/right`
+//
+// Compare a base package against two descendants and classify how each declaration changed.
+func threeWayCommand(args []string) {
+	flags := flag.NewFlagSet("threeway", flag.ExitOnError)
+
+	var pkgPathsArg stringSliceArg
+	flags.Var(&pkgPathsArg, "paths", "Comma-separated base, left and right packages' paths")
+
+	var opts eqgo.Options
+	addOptionsFlags(flags, &opts)
+
+	flags.Parse(args)
+
+	if len(pkgPathsArg) != 3 {
+		exitWithError(fmt.Errorf("--paths requires the base, left and right packages' paths"))
+	}
+	basePkg, baseFSet := loadPackage(pkgPathsArg[0])
+	lhsPkg, lhsFSet := loadPackage(pkgPathsArg[1])
+	rhsPkg, rhsFSet := loadPackage(pkgPathsArg[2])
+
+	result, err := eqgo.CompareThreeWay(basePkg, baseFSet, lhsPkg, lhsFSet, rhsPkg, rhsFSet, &opts)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	changed := 0
+	for _, d := range result.Declarations {
+		if d.Change == eqgo.Unchanged {
+			continue
+		}
+		changed++
+		fmt.Printf("%-34s %s\n", d.Change.String()+":", d.Key)
+	}
+
+	switch {
+	case changed == 0:
+		fmt.Printf("No declarations changed.\n")
+	case result.Conflict:
+		fmt.Printf("\nSome declarations conflict.\n")
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	eqgo "github.com/kevinmbeaulieu/eq-go/eq-go"
+)
+
+// Usage:
+//
+//	`go run path/to/eq-go-cli verify [--in-place] [--update] path/to/committed -- go generate ./...`
+//	`go run path/to/eq-go-cli verify path/to/committed -- sh -c 'mygen -o "$EQGO_OUT"'`
+//
+// Rerun a generator into a temporary directory and check that its output is equivalent to the
+// committed output. Generators which don't regenerate their output in place must write it to the
+// directory named by $EQGO_OUT. Exits with a non-zero status if the outputs are not equivalent.
+func verifyCommand(args []string) {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+
+	var gen eqgo.Generator
+	flags.BoolVar(&gen.InPlace, "in-place", false, "Run the generator in a copy of the module containing the committed directory, for generators which regenerate their output in place")
+
+	var update bool
+	flags.BoolVar(&update, "update", false, "Overwrite the committed output if it only differs from the regenerated output in non-semantic ways")
+
+	var opts eqgo.Options
+	addOptionsFlags(flags, &opts)
+	flags.BoolVar(&opts.IncludeTests, "tests", false, "Also compare test files and external test packages")
+
+	flags.Parse(args)
+
+	if flags.NArg() < 2 {
+		exitWithError(fmt.Errorf("verify requires a directory and a generator command"))
+	}
+	dir := flags.Arg(0)
+	gen.Command = flags.Args()[1:]
+	if gen.Command[0] == "--" {
+		gen.Command = gen.Command[1:]
+	}
+
+	result, err := eqgo.VerifyGenerated(dir, gen, update, &opts)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if !result.Result.Equivalent {
+		fmt.Printf("%s is out of date.\n\n%s\n", dir, result.Result.Format(nil))
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s is up to date.\n", dir)
+	for _, path := range result.Updated {
+		fmt.Printf("updated %s\n", path)
+	}
+}
@@ -0,0 +1,392 @@
+package eqgo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// APIChangeKind identifies how an exported symbol differs between two packages.
+type APIChangeKind int
+
+const (
+	// The symbol only exists in the second package.
+	APIAdded APIChangeKind = iota
+
+	// The symbol only exists in the first package.
+	APIRemoved
+
+	// The symbol exists in both packages, but its signature differs.
+	APIChanged
+)
+
+func (k APIChangeKind) String() string {
+	switch k {
+	case APIAdded:
+		return "added"
+	case APIRemoved:
+		return "removed"
+	case APIChanged:
+		return "changed"
+	}
+	return fmt.Sprintf("APIChangeKind(%d)", int(k))
+}
+
+// APIChange describes a difference in a single exported symbol between two packages.
+type APIChange struct {
+	// Identifies the symbol, e.g. "func F", "type T", "field T.X", "method T.M", "method (*T).M"
+	// or "interface method I.M".
+	Symbol string
+
+	Kind APIChangeKind
+
+	// Signatures of the symbol in the first and second package. Old is empty for added symbols and
+	// New is empty for removed symbols.
+	Old, New string
+
+	// Whether code written against the first package keeps compiling against the second package
+	// despite this change, e.g. adding a struct field is compatible but adding a method to an
+	// interface or changing a parameter type is breaking.
+	Compatible bool
+}
+
+// APIResult describes the outcome of comparing the exported API surfaces of two packages.
+type APIResult struct {
+	// Whether the packages export the same API.
+	Equivalent bool
+
+	// Differences between the packages' APIs, sorted by symbol.
+	Changes []APIChange
+
+	// Whether all of the changes are backward-compatible.
+	Compatible bool
+}
+
+// Format returns a message describing the differences found, one symbol per line, marking
+// additions with "+", removals with "-" and signature changes with "~", and flagging breaking
+// changes.
+func (r *APIResult) Format() string {
+	if r.Equivalent {
+		return "equivalent"
+	}
+
+	var builder strings.Builder
+	fmt.Fprint(&builder, "not equivalent:")
+	for _, c := range r.Changes {
+		breaking := ""
+		if !c.Compatible {
+			breaking = " (breaking)"
+		}
+
+		switch c.Kind {
+		case APIAdded:
+			fmt.Fprintf(&builder, "\n+ %s%s", c.New, breaking)
+		case APIRemoved:
+			fmt.Fprintf(&builder, "\n- %s%s", c.Old, breaking)
+		case APIChanged:
+			fmt.Fprintf(&builder, "\n~ %s%s\n    was: %s\n    now: %s", c.Symbol, breaking, c.Old, c.New)
+		}
+	}
+	return builder.String()
+}
+
+// CompareAPI compares only the exported API surfaces of the Go packages made up of the files in a
+// and b: the exported constants, variables, functions and types, the exported fields of exported
+// struct types, and the method sets of exported types. Unexported declarations and function bodies
+// are ignored, except that a struct type which stops being comparable is a breaking change. Type
+// parameters and their constraints are part of the signatures of generic functions and types.
+// Every file must belong to the same package as the others on its side.
+//
+// Both packages are type-checked with go/types, resolving imports from source using the local
+// GOROOT and module cache. Packages which fail to type-check cause an error to be returned.
+func CompareAPI(a []*ast.File, fsetA *token.FileSet, b []*ast.File, fsetB *token.FileSet) (*APIResult, error) {
+	nameA, err := filesPackageName(a, fsetA)
+	if err != nil {
+		return nil, err
+	}
+	nameB, err := filesPackageName(b, fsetB)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := newImporter()
+	checkedA, err := typeCheck(nameA, a, fsetA, imp)
+	if err != nil {
+		return nil, err
+	}
+	checkedB, err := typeCheck(nameB, b, fsetB, imp)
+	if err != nil {
+		return nil, err
+	}
+
+	return compareAPISurfaces(apiSurface(checkedA.pkg), apiSurface(checkedB.pkg)), nil
+}
+
+// A single exported symbol of a package's API surface.
+type apiSymbol struct {
+	// Signature of the symbol, with names from the package itself left unqualified.
+	signature string
+
+	// Signature of a struct field without its tag, so that tag-only changes can be told apart.
+	untagged string
+
+	// For interface types, whether the interface has unexported methods, so can't be implemented
+	// outside of the package.
+	sealed bool
+
+	// For struct types, whether values of the type can't be compared with == or used as map keys.
+	incomparable bool
+
+	// For interface methods, the name of the interface.
+	owner string
+}
+
+// Collect the exported API surface of a type-checked package, keyed by symbol.
+func apiSurface(pkg *types.Package) map[string]apiSymbol {
+	qualifier := func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+		return p.Path()
+	}
+
+	surface := make(map[string]apiSymbol)
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+
+		switch obj := obj.(type) {
+		case *types.Const:
+			surface["const "+name] = apiSymbol{
+				signature: fmt.Sprintf("const %s %s = %s", name, types.TypeString(obj.Type(), qualifier), obj.Val().ExactString()),
+			}
+		case *types.Var:
+			surface["var "+name] = apiSymbol{signature: types.ObjectString(obj, qualifier)}
+		case *types.Func:
+			sig := obj.Type().(*types.Signature)
+			surface["func "+name] = apiSymbol{
+				signature: fmt.Sprintf("func %s%s%s", name, typeParamsString(sig.TypeParams(), qualifier), signatureString(sig, qualifier)),
+			}
+		case *types.TypeName:
+			addTypeToAPISurface(surface, obj, qualifier)
+		}
+	}
+	return surface
+}
+
+func addTypeToAPISurface(surface map[string]apiSymbol, obj *types.TypeName, qualifier types.Qualifier) {
+	name := obj.Name()
+	tparams := ""
+	if named, ok := obj.Type().(*types.Named); ok {
+		tparams = typeParamsString(named.TypeParams(), qualifier)
+	}
+
+	if obj.IsAlias() {
+		// The type of an alias is the alias itself, so describe the type it stands for.
+		surface["type "+name] = apiSymbol{
+			signature: fmt.Sprintf("type %s = %s", name, types.TypeString(types.Unalias(obj.Type()), qualifier)),
+		}
+		return
+	}
+
+	switch u := obj.Type().Underlying().(type) {
+	case *types.Struct:
+		// Unexported fields can still make a struct incomparable, which breaks uses of it with ==
+		// or as a map key.
+		signature := fmt.Sprintf("type %s%s struct", name, tparams)
+		incomparable := !types.Comparable(obj.Type())
+		if incomparable {
+			signature += " (not comparable)"
+		}
+		surface["type "+name] = apiSymbol{signature: signature, incomparable: incomparable}
+		for i := 0; i < u.NumFields(); i++ {
+			field := u.Field(i)
+			if !field.Exported() {
+				continue
+			}
+			untagged := fmt.Sprintf("field %s.%s %s", name, field.Name(), types.TypeString(field.Type(), qualifier))
+			if field.Embedded() {
+				untagged += " (embedded)"
+			}
+			signature := untagged
+			if tag := u.Tag(i); tag != "" {
+				signature += fmt.Sprintf(" %q", tag)
+			}
+			surface[fmt.Sprintf("field %s.%s", name, field.Name())] = apiSymbol{signature: signature, untagged: untagged}
+		}
+
+	case *types.Interface:
+		sealed := false
+		for i := 0; i < u.NumMethods(); i++ {
+			method := u.Method(i)
+			if !method.Exported() {
+				// Unexported methods can't be named outside the package, but they prevent the
+				// interface from being implemented outside of it.
+				sealed = true
+				continue
+			}
+			surface[fmt.Sprintf("interface method %s.%s", name, method.Name())] = apiSymbol{
+				signature: fmt.Sprintf("interface method %s.%s%s", name, method.Name(), signatureString(method.Type().(*types.Signature), qualifier)),
+				owner:     name,
+			}
+		}
+
+		signature := fmt.Sprintf("type %s%s interface", name, tparams)
+		if sealed {
+			signature += " (has unexported methods)"
+		}
+		surface["type "+name] = apiSymbol{signature: signature, sealed: sealed}
+		return
+
+	default:
+		surface["type "+name] = apiSymbol{
+			signature: fmt.Sprintf("type %s%s %s", name, tparams, types.TypeString(u, qualifier)),
+		}
+	}
+
+	// Methods, including those promoted from embedded fields. Methods with a value receiver are in
+	// the method sets of both T and *T, so are only listed for T.
+	valueMethods := types.NewMethodSet(obj.Type())
+	pointerMethods := types.NewMethodSet(types.NewPointer(obj.Type()))
+	for i := 0; i < pointerMethods.Len(); i++ {
+		method := pointerMethods.At(i).Obj()
+		if !method.Exported() {
+			continue
+		}
+
+		key := fmt.Sprintf("method %s.%s", name, method.Name())
+		receiver := name
+		if valueMethods.Lookup(method.Pkg(), method.Name()) == nil {
+			key = fmt.Sprintf("method (*%s).%s", name, method.Name())
+			receiver = "*" + name
+		}
+
+		surface[key] = apiSymbol{
+			signature: fmt.Sprintf("func (%s) %s%s", receiver, method.Name(), signatureString(method.Type().(*types.Signature), qualifier)),
+		}
+	}
+}
+
+// Describe the type parameters of a generic function or type along with their constraints, e.g.
+// "[K comparable, V any]", or "" if there are none.
+func typeParamsString(tparams *types.TypeParamList, qualifier types.Qualifier) string {
+	if tparams.Len() == 0 {
+		return ""
+	}
+
+	var elems []string
+	for i := 0; i < tparams.Len(); i++ {
+		tparam := tparams.At(i)
+		elems = append(elems, tparam.Obj().Name()+" "+types.TypeString(tparam.Constraint(), qualifier))
+	}
+	return "[" + strings.Join(elems, ", ") + "]"
+}
+
+// Describe a function signature by its parameter and result types only, since renaming parameters
+// doesn't affect callers.
+func signatureString(sig *types.Signature, qualifier types.Qualifier) string {
+	tupleString := func(t *types.Tuple, variadic bool) string {
+		var elems []string
+		for i := 0; i < t.Len(); i++ {
+			if variadic && i == t.Len()-1 {
+				elems = append(elems, "..."+types.TypeString(t.At(i).Type().(*types.Slice).Elem(), qualifier))
+				continue
+			}
+			elems = append(elems, types.TypeString(t.At(i).Type(), qualifier))
+		}
+		return strings.Join(elems, ", ")
+	}
+
+	s := "(" + tupleString(sig.Params(), sig.Variadic()) + ")"
+	switch sig.Results().Len() {
+	case 0:
+	case 1:
+		s += " " + tupleString(sig.Results(), false)
+	default:
+		s += " (" + tupleString(sig.Results(), false) + ")"
+	}
+	return s
+}
+
+func compareAPISurfaces(a map[string]apiSymbol, b map[string]apiSymbol) *APIResult {
+	var keys []string
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	r := &APIResult{}
+	for _, k := range keys {
+		symbolA, okA := a[k]
+		symbolB, okB := b[k]
+		var c APIChange
+		switch {
+		case !okA:
+			c = APIChange{Symbol: k, Kind: APIAdded, New: symbolB.signature}
+		case !okB:
+			c = APIChange{Symbol: k, Kind: APIRemoved, Old: symbolA.signature}
+		case symbolA.signature != symbolB.signature:
+			c = APIChange{Symbol: k, Kind: APIChanged, Old: symbolA.signature, New: symbolB.signature}
+		default:
+			continue
+		}
+		c.Compatible = apiChangeCompatible(c.Kind, symbolA, symbolB, a)
+		r.Changes = append(r.Changes, c)
+	}
+
+	r.Equivalent = len(r.Changes) == 0
+	r.Compatible = true
+	for _, c := range r.Changes {
+		if !c.Compatible {
+			r.Compatible = false
+		}
+	}
+	return r
+}
+
+// Classify a change to a symbol as backward-compatible or breaking, along the lines of
+// golang.org/x/exp/apidiff. surfaceA is the API surface of the first package.
+func apiChangeCompatible(kind APIChangeKind, a apiSymbol, b apiSymbol, surfaceA map[string]apiSymbol) bool {
+	switch kind {
+	case APIAdded:
+		if b.owner != "" {
+			// Adding a method to an interface breaks implementations of it outside of the package,
+			// unless the interface couldn't be implemented outside of the package to begin with.
+			owner, ok := surfaceA["type "+b.owner]
+			return !ok || owner.sealed
+		}
+		return true
+
+	case APIRemoved:
+		return false
+
+	case APIChanged:
+		// Changing only a struct field's tag still allows the field to be used the same way.
+		if a.untagged != "" && a.untagged == b.untagged {
+			return true
+		}
+		// An interface which loses its unexported methods can now be implemented outside of the
+		// package, which doesn't affect existing uses of it.
+		if a.sealed && b.signature == strings.TrimSuffix(a.signature, " (has unexported methods)") {
+			return true
+		}
+		// A struct which becomes comparable can still be used in the same ways, but one which stops
+		// being comparable can no longer be compared with == or used as a map key.
+		if a.incomparable && !b.incomparable && b.signature == strings.TrimSuffix(a.signature, " (not comparable)") {
+			return true
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package eqgo
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestCompareAPI(t *testing.T) {
+	testCases := []struct {
+		a    string
+		b    string
+		want []APIChange
+	}{
+		{
+			a:    "func F(x int) int { return helper(x) }; func helper(x int) int { return x }",
+			b:    "func F(y int) int { return y * 1 }",
+			want: nil,
+		},
+		{
+			a:    "type T struct { X int; y string }; func (T) M() {}; func (t *T) n() {}",
+			b:    "type T struct { y bool; X int }; func (T) M() {}",
+			want: nil,
+		},
+		{
+			a: "func F(x int) error { return nil }",
+			b: "func F(x int64) error { return nil }",
+			want: []APIChange{
+				{Symbol: "func F", Kind: APIChanged, Old: "func F(int) error", New: "func F(int64) error"},
+			},
+		},
+		{
+			a: "const A = 1; var V int",
+			b: "const B = 1; var V int",
+			want: []APIChange{
+				{Symbol: "const A", Kind: APIRemoved, Old: "const A untyped int = 1"},
+				{Symbol: "const B", Kind: APIAdded, New: "const B untyped int = 1", Compatible: true},
+			},
+		},
+		{
+			a: "type T struct{}; func (T) M() {}",
+			b: "type T struct{}; func (*T) M() {}",
+			want: []APIChange{
+				{Symbol: "method (*T).M", Kind: APIAdded, New: "func (*T) M()", Compatible: true},
+				{Symbol: "method T.M", Kind: APIRemoved, Old: "func (T) M()"},
+			},
+		},
+		{
+			a: "type I interface { M() }",
+			b: "type I interface { M(); N(...string) }",
+			want: []APIChange{
+				{Symbol: "interface method I.N", Kind: APIAdded, New: "interface method I.N(...string)"},
+			},
+		},
+		{
+			a: "type T struct{ X int }",
+			b: "type T struct{ X int `json:\"x\"`; Y string }",
+			want: []APIChange{
+				{Symbol: "field T.X", Kind: APIChanged, Old: "field T.X int", New: "field T.X int \"json:\\\"x\\\"\"", Compatible: true},
+				{Symbol: "field T.Y", Kind: APIAdded, New: "field T.Y string", Compatible: true},
+			},
+		},
+		{
+			a: "type I interface { M(); m() }",
+			b: "type I interface { M(); N() }",
+			want: []APIChange{
+				{Symbol: "interface method I.N", Kind: APIAdded, New: "interface method I.N()", Compatible: true},
+				{Symbol: "type I", Kind: APIChanged, Old: "type I interface (has unexported methods)", New: "type I interface", Compatible: true},
+			},
+		},
+		{
+			a: "type E struct{}; func (E) M() {}; type T struct{ E }",
+			b: "type E struct{}; func (E) M() {}; type T struct{ e E }",
+			want: []APIChange{
+				{Symbol: "field T.E", Kind: APIRemoved, Old: "field T.E E (embedded)"},
+				{Symbol: "method T.M", Kind: APIRemoved, Old: "func (T) M()"},
+			},
+		},
+		{
+			a: "type T = int",
+			b: "type T = string",
+			want: []APIChange{
+				{Symbol: "type T", Kind: APIChanged, Old: "type T = int", New: "type T = string"},
+			},
+		},
+		{
+			a: "func F[T any](x T) {}",
+			b: "func F[T comparable](x T) {}",
+			want: []APIChange{
+				{Symbol: "func F", Kind: APIChanged, Old: "func F[T any](T)", New: "func F[T comparable](T)"},
+			},
+		},
+		{
+			a: "type S[T any] struct{ X T }",
+			b: "type S[T comparable] struct{ X T }",
+			want: []APIChange{
+				{Symbol: "type S", Kind: APIChanged, Old: "type S[T any] struct (not comparable)", New: "type S[T comparable] struct"},
+			},
+		},
+		{
+			a: "type T struct{ X int }",
+			b: "type T struct{ X int; y func() }",
+			want: []APIChange{
+				{Symbol: "type T", Kind: APIChanged, Old: "type T struct", New: "type T struct (not comparable)"},
+			},
+		},
+		{
+			a: "type T struct{ X int; y []int }",
+			b: "type T struct{ X int; y string }",
+			want: []APIChange{
+				{Symbol: "type T", Kind: APIChanged, Old: "type T struct (not comparable)", New: "type T struct", Compatible: true},
+			},
+		},
+	}
+	for _, c := range testCases {
+		fset := token.NewFileSet()
+		fileA, err := parser.ParseFile(fset, "a.go", "package p; "+c.a, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileB, err := parser.ParseFile(fset, "b.go", "package p; "+c.b, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := CompareAPI([]*ast.File{fileA}, fset, []*ast.File{fileB}, fset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Equivalent != (len(c.want) == 0) {
+			t.Errorf("CompareAPI(%q, %q).Equivalent == %t, want %t", c.a, c.b, got.Equivalent, len(c.want) == 0)
+		}
+		if compatible := allCompatible(c.want); got.Compatible != compatible {
+			t.Errorf("CompareAPI(%q, %q).Compatible == %t, want %t", c.a, c.b, got.Compatible, compatible)
+		}
+		if !equalAPIChanges(got.Changes, c.want) {
+			t.Errorf("CompareAPI(%q, %q).Changes == %+v, want %+v", c.a, c.b, got.Changes, c.want)
+		}
+	}
+}
+
+func equalAPIChanges(a []APIChange, b []APIChange) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func allCompatible(changes []APIChange) bool {
+	for _, c := range changes {
+		if !c.Compatible {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package eqgo
+
+import (
+	"fmt"
+	"go/build"
+
+	"github.com/kevinmbeaulieu/eq-go/eq-go/loader"
+)
+
+// BuildConfigsResult describes the outcome of comparing two package directories under several
+// build configurations.
+type BuildConfigsResult struct {
+	// Whether the packages are equivalent under every configuration.
+	Equivalent bool
+
+	// Result under each configuration, in the order the configurations were given.
+	Configs []BuildConfigResult
+}
+
+// BuildConfigResult describes the outcome of comparing two package directories under a single
+// build configuration.
+type BuildConfigResult struct {
+	Config loader.Config
+
+	// Whether a directory has no Go files selected by the configuration. If only one side has no
+	// files, the packages are not equivalent under the configuration and Result is nil. If neither
+	// side has any, they are equivalent and Result is nil.
+	LeftMissing, RightMissing bool
+
+	Result *Result
+}
+
+// Equivalent reports whether the packages are equivalent under the configuration.
+func (r BuildConfigResult) Equivalent() bool {
+	if r.Result == nil {
+		return r.LeftMissing == r.RightMissing
+	}
+	return r.Result.Equivalent
+}
+
+// Diverging returns the configurations under which the packages are not equivalent.
+func (r *BuildConfigsResult) Diverging() []loader.Config {
+	var configs []loader.Config
+	for _, c := range r.Configs {
+		if !c.Equivalent() {
+			configs = append(configs, c.Config)
+		}
+	}
+	return configs
+}
+
+// CompareBuildConfigs compares the library packages in the directories leftDir and rightDir once
+// for each of configs, selecting the files of each package by build constraints for that
+// configuration with loader, so that e.g. _linux.go and _windows.go variants and tag-gated files
+// are compared only under the configurations which build them. Each comparison is as for
+// CompareLoadedPackages with opts.
+//
+// opts may be nil.
+func CompareBuildConfigs(leftDir string, rightDir string, configs []loader.Config, opts *Options) (*BuildConfigsResult, error) {
+	r := &BuildConfigsResult{Equivalent: true}
+	for i := range configs {
+		config := configs[i]
+		c := BuildConfigResult{Config: config}
+
+		left, err := loadForConfig(&config, leftDir)
+		if err != nil {
+			return nil, err
+		}
+		right, err := loadForConfig(&config, rightDir)
+		if err != nil {
+			return nil, err
+		}
+
+		includeTests := opts != nil && opts.IncludeTests
+		c.LeftMissing = left == nil || (len(left.Files) == 0 && !includeTests)
+		c.RightMissing = right == nil || (len(right.Files) == 0 && !includeTests)
+		if !c.LeftMissing && !c.RightMissing {
+			c.Result, err = CompareLoadedPackages(left, right, opts)
+			if err != nil {
+				return nil, fmt.Errorf("comparing under %v: %v", config, err)
+			}
+		}
+
+		if !c.Equivalent() {
+			r.Equivalent = false
+		}
+		r.Configs = append(r.Configs, c)
+	}
+	return r, nil
+}
+
+// Load the packages in dir under a configuration. Returns nil if the configuration selects no Go
+// files in dir.
+func loadForConfig(config *loader.Config, dir string) (*loader.Packages, error) {
+	p, err := config.Load(dir)
+	if _, ok := err.(*build.NoGoError); ok {
+		return nil, nil
+	}
+	return p, err
+}
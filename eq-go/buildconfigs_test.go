@@ -0,0 +1,45 @@
+package eqgo
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/kevinmbeaulieu/eq-go/eq-go/loader"
+)
+
+func TestCompareBuildConfigs(t *testing.T) {
+	left := writeTree(t, map[string]string{
+		"p.go":         "package p\n",
+		"p_linux.go":   "package p\nfunc F() int { return 1 }\n",
+		"p_windows.go": "package p\nfunc F() int { return 2 }\n",
+	})
+	defer os.RemoveAll(left)
+
+	right := writeTree(t, map[string]string{
+		"p.go":         "package p\n",
+		"p_linux.go":   "package p\nfunc F() int { return 1 }\n",
+		"p_windows.go": "package p\nfunc F() int { return 3 }\n",
+		"extra.go":     "//go:build extra\n// +build extra\n\npackage p\nvar X = 1\n",
+	})
+	defer os.RemoveAll(right)
+
+	linux := loader.Config{GOOS: "linux", GOARCH: "amd64"}
+	windows := loader.Config{GOOS: "windows", GOARCH: "amd64"}
+	linuxExtra := loader.Config{GOOS: "linux", GOARCH: "amd64", Tags: []string{"extra"}}
+
+	got, err := CompareBuildConfigs(left, right, []loader.Config{linux, windows, linuxExtra}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Equivalent {
+		t.Errorf("CompareBuildConfigs().Equivalent == true, want false")
+	}
+	if want := []loader.Config{windows, linuxExtra}; !reflect.DeepEqual(got.Diverging(), want) {
+		t.Errorf("CompareBuildConfigs().Diverging() == %v, want %v", got.Diverging(), want)
+	}
+	if s := linuxExtra.String(); s != "linux/amd64 [extra]" {
+		t.Errorf("Config.String() == %q, want %q", s, "linux/amd64 [extra]")
+	}
+}
@@ -0,0 +1,168 @@
+package eqgo
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/printer"
+	"go/token"
+	"reflect"
+)
+
+// Helpers to rewrite files into the canonical form the comparator works with internally, so that
+// equivalent inputs can be printed identically and diffed with ordinary tools.
+
+var posType = reflect.TypeOf(token.NoPos)
+
+// Canonicalize returns a copy of f rewritten into a deterministic canonical form: grouped and
+// multi-name declarations are flattened, literals and constant expressions are folded, redundant
+// parentheses and all comments are removed, imports are merged into a single sorted declaration,
+// and the remaining declarations are sorted in the order used when comparing them. Duplicate
+// declarations are removed.
+//
+// Files which are equivalent under these normalizations canonicalize to identical trees, and print
+// byte-for-byte identically with FormatCanonical. f itself is left unchanged.
+func Canonicalize(f *ast.File) *ast.File {
+	defer beginComparison("", "")()
+	return canonicalize(f)
+}
+
+// Rewrite a copy of f into canonical form as for Canonicalize. Must be called while a comparison is
+// in progress, so that sorting isn't affected by the package names of another comparison.
+func canonicalize(f *ast.File) *ast.File {
+	c := canonicalTree(f)
+	clearPositions(c)
+	return c
+}
+
+// Rewrite a copy of f into canonical form as for canonicalize, but keep the positions of its nodes
+// in the original source.
+func canonicalTree(f *ast.File) *ast.File {
+	c := cloneNode(f).(*ast.File)
+
+	normalizeFile(c, &Options{
+		FoldConstants:        true,
+		NormalizeParentheses: true,
+		FlattenDeclarations:  true,
+	})
+
+	badDecls, genDecls, funcDecls := splitDecls(c.Decls)
+	sortGenDeclList(&genDecls)
+	sortFuncDeclList(&funcDecls)
+	sortImportList(&c.Imports)
+
+	var decls []ast.Decl
+	if len(c.Imports) > 0 {
+		importDecl := &ast.GenDecl{Tok: token.IMPORT}
+		for _, s := range c.Imports {
+			importDecl.Specs = append(importDecl.Specs, s)
+		}
+		if len(c.Imports) > 1 {
+			// Any valid position makes the printer use a parenthesized group.
+			importDecl.Lparen = 1
+		}
+		decls = append(decls, importDecl)
+	}
+	for _, d := range genDecls {
+		decls = append(decls, d)
+	}
+	for _, d := range funcDecls {
+		decls = append(decls, d)
+	}
+	for _, d := range badDecls {
+		decls = append(decls, d)
+	}
+	c.Decls = decls
+
+	c.Doc = nil
+	c.Comments = nil
+
+	return c
+}
+
+// CanonicalizePackage merges files, which must all belong to the same package, into a single file
+// and returns it in canonical form, as for Canonicalize. The files themselves are left unchanged.
+func CanonicalizePackage(files []*ast.File) (*ast.File, error) {
+	name, err := filesPackageName(files, nil)
+	if err != nil {
+		return nil, err
+	}
+	return Canonicalize(mergeFiles(name, files)), nil
+}
+
+// FormatCanonical canonicalizes f as for Canonicalize and prints the result in gofmt style.
+func FormatCanonical(f *ast.File) ([]byte, error) {
+	c := Canonicalize(f)
+
+	// The canonical tree has no positions, so print it once to lay it out, separating top-level
+	// declarations by blank lines, and once more to format the result the way gofmt would.
+	fset := token.NewFileSet()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s\n", c.Name.Name)
+	for _, d := range c.Decls {
+		buf.WriteString("\n")
+		if err := printer.Fprint(&buf, fset, d); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n")
+	}
+	return format.Source(buf.Bytes())
+}
+
+// Print a single declaration of a canonical tree.
+func printCanonicalDecl(d ast.Decl) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, token.NewFileSet(), d); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Reset every position below n, except for the group markers of grouped declarations, so that the
+// printer lays out the tree independently of where its nodes came from.
+func clearPositions(n ast.Node) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+
+		v := reflect.ValueOf(n)
+		if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return true
+		}
+
+		s := v.Elem()
+		for i := 0; i < s.NumField(); i++ {
+			field := s.Field(i)
+			if field.Type() != posType {
+				continue
+			}
+			if _, ok := n.(*ast.GenDecl); ok {
+				name := s.Type().Field(i).Name
+				if (name == "Lparen" || name == "Rparen") && field.Int() != 0 {
+					field.SetInt(1)
+					continue
+				}
+			}
+			field.SetInt(0)
+		}
+
+		// Comments are dropped entirely.
+		switch x := n.(type) {
+		case *ast.Field:
+			x.Doc, x.Comment = nil, nil
+		case *ast.ImportSpec:
+			x.Doc, x.Comment = nil, nil
+		case *ast.ValueSpec:
+			x.Doc, x.Comment = nil, nil
+		case *ast.TypeSpec:
+			x.Doc, x.Comment = nil, nil
+		case *ast.GenDecl:
+			x.Doc = nil
+		case *ast.FuncDecl:
+			x.Doc = nil
+		}
+		return true
+	})
+}
@@ -0,0 +1,81 @@
+package eqgo
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestFormatCanonical(t *testing.T) {
+	testCases := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{
+			a:    "import (\"os\"; \"fmt\"); func B() { fmt.Println() }; func A() { os.Exit(0) }",
+			b:    "import \"fmt\"\nimport \"os\"\n// A exits.\nfunc A() { os.Exit((0)) }; func B() { fmt.Println() }",
+			want: true,
+		},
+		{
+			a:    "var (x, y int); const c = 0x10",
+			b:    "const c = 16; var y int; var x int",
+			want: true,
+		},
+		{
+			a:    "func F() { a(); b() }",
+			b:    "func F() { b(); a() }",
+			want: false,
+		},
+	}
+	for _, c := range testCases {
+		fset := token.NewFileSet()
+		fileA, err := parser.ParseFile(fset, "a.go", "package p; "+c.a, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileB, err := parser.ParseFile(fset, "b.go", "package p; "+c.b, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		outA, err := FormatCanonical(fileA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outB, err := FormatCanonical(fileB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(outA) == string(outB); got != c.want {
+			t.Errorf("FormatCanonical(%q) == FormatCanonical(%q) is %t, want %t\n%s\n%s", c.a, c.b, got, c.want, outA, outB)
+		}
+
+		// Canonical output is already in canonical form.
+		canonical, err := parser.ParseFile(fset, "canonical.go", outA, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := FormatCanonical(canonical)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(again) != string(outA) {
+			t.Errorf("FormatCanonical is not idempotent for %q:\n%s\n%s", c.a, outA, again)
+		}
+	}
+}
+
+func TestCanonicalizeLeavesInputUnchanged(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "a.go", "package p; func B() {}; func A() {}", parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Canonicalize(f)
+	if name := f.Decls[0].(*ast.FuncDecl).Name.Name; name != "B" {
+		t.Errorf("Canonicalize reordered its input: first declaration is %s, want B", name)
+	}
+}
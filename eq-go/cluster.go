@@ -0,0 +1,128 @@
+package eqgo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// ClusterResult describes how a number of packages partition into classes of equivalent packages.
+type ClusterResult struct {
+	// Equivalence classes, in order of their first member. Each class lists the indices of its
+	// members in the input, in increasing order. The first member of each class is its
+	// representative.
+	Classes [][]int
+
+	// Declarations which differ between the representatives of each pair of classes, ordered by
+	// class.
+	Differences []ClassDifference
+}
+
+// ClassDifference describes how the representatives of two equivalence classes differ.
+type ClassDifference struct {
+	// Indices of the two classes in ClusterResult.Classes, with A < B.
+	A, B int
+
+	// Keys of the top-level declarations which differ between the classes' representatives or only
+	// exist in one of them, sorted.
+	Declarations []string
+}
+
+// ClusterPackages partitions the Go packages made up of the files in each element of pkgs into
+// classes of packages which are equivalent to each other, using the same notion of equivalence as
+// ComparePackageFiles with opts. fsets holds the file set of each package.
+//
+// Each package is compared only with the representative of each class found so far, rather than
+// with every other package, and the differences between classes are found by comparing their
+// representatives declaration by declaration.
+//
+// opts may be nil. Subset mode is not supported, since it does not define an equivalence relation.
+// Comparing initialization order or including tests is not supported either.
+func ClusterPackages(pkgs [][]*ast.File, fsets []*token.FileSet, opts *Options) (*ClusterResult, error) {
+	if len(pkgs) != len(fsets) {
+		return nil, fmt.Errorf("got %d packages but %d file sets", len(pkgs), len(fsets))
+	}
+	names := make([]string, len(pkgs))
+	for i, pkg := range pkgs {
+		var err error
+		if names[i], err = filesPackageName(pkg, fsets[i]); err != nil {
+			return nil, err
+		}
+	}
+	if opts != nil && opts.Subset {
+		return nil, fmt.Errorf("subset mode is not supported when clustering packages")
+	}
+	if opts != nil && opts.InitOrder {
+		return nil, fmt.Errorf("comparing initialization order is not supported when clustering packages")
+	}
+	if opts != nil && opts.IncludeTests {
+		return nil, fmt.Errorf("comparing tests is not supported when clustering packages")
+	}
+
+	defer beginComparison("", "")()
+
+	if opts != nil && opts.TypeCheck {
+		if err := startTypeCheckedComparisonOfPackages(names, pkgs, fsets); err != nil {
+			return nil, err
+		}
+		defer endTypeCheckedComparison()
+	}
+
+	files := make([]*ast.File, len(pkgs))
+	for i, pkg := range pkgs {
+		files[i] = cloneForComparison(mergeFiles(names[i], sortedFiles(pkg, fsets[i]))).(*ast.File)
+		normalizeFile(files[i], opts)
+	}
+
+	// Compare with the names of the two packages being compared treated as equivalent, as in
+	// ComparePackages.
+	withPackageNames := func(i int, j int) {
+		equivalentPackageNameA = names[i]
+		equivalentPackageNameB = names[j]
+	}
+
+	r := &ClusterResult{}
+	for i := range files {
+		found := false
+		for c, class := range r.Classes {
+			withPackageNames(class[0], i)
+			if cmp, _ := compareFiles(files[class[0]], files[i]); cmp == 0 {
+				r.Classes[c] = append(class, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			r.Classes = append(r.Classes, []int{i})
+		}
+	}
+
+	for a := range r.Classes {
+		for b := a + 1; b < len(r.Classes); b++ {
+			i, j := r.Classes[a][0], r.Classes[b][0]
+			withPackageNames(i, j)
+			r.Differences = append(r.Differences, ClassDifference{
+				A:            a,
+				B:            b,
+				Declarations: differingDeclarations(files[i], files[j]),
+			})
+		}
+	}
+
+	return r, nil
+}
+
+// Keys of the top-level declarations which differ between two files or only exist in one of them.
+func differingDeclarations(a *ast.File, b *ast.File) []string {
+	var keys []string
+	for _, pair := range matchDeclarations(collectDeclarations(a), collectDeclarations(b)) {
+		if pair.left == nil || pair.right == nil {
+			keys = append(keys, pair.key)
+			continue
+		}
+		if cmp, _ := compareDecls(pair.left.decl, pair.right.decl); cmp != 0 {
+			keys = append(keys, pair.key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,56 @@
+package eqgo
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestClusterPackages(t *testing.T) {
+	sources := []string{
+		"func F() int { return 1 }; type T int",
+		"type T int; func F() int { return 1 }",
+		"func F() int { return 2 }; type T int",
+		"type T int; func F() int { return 1 }; func G() {}",
+		"func F() int { return 2 }; type T int",
+	}
+
+	var pkgs [][]*ast.File
+	var fsets []*token.FileSet
+	for _, src := range sources {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", "package p; "+src, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkgs = append(pkgs, []*ast.File{f})
+		fsets = append(fsets, fset)
+	}
+
+	got, err := ClusterPackages(pkgs, fsets, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantClasses := [][]int{{0, 1}, {2, 4}, {3}}
+	if !reflect.DeepEqual(got.Classes, wantClasses) {
+		t.Errorf("ClusterPackages().Classes == %v, want %v", got.Classes, wantClasses)
+	}
+
+	wantDifferences := []ClassDifference{
+		{A: 0, B: 1, Declarations: []string{"func F"}},
+		{A: 0, B: 2, Declarations: []string{"func G"}},
+		{A: 1, B: 2, Declarations: []string{"func F", "func G"}},
+	}
+	if !reflect.DeepEqual(got.Differences, wantDifferences) {
+		t.Errorf("ClusterPackages().Differences == %+v, want %+v", got.Differences, wantDifferences)
+	}
+
+	for _, opts := range []*Options{{Subset: true}, {InitOrder: true}, {IncludeTests: true}} {
+		if _, err := ClusterPackages(pkgs, fsets, opts); err == nil {
+			t.Errorf("ClusterPackages() with options %+v returned no error", *opts)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"go/token"
 	"reflect"
 	"strings"
+	"sync"
 )
 
 // Functions for comparing language entities for equivalence.
@@ -20,6 +21,10 @@ import (
 var equivalentPackageNameA string
 var equivalentPackageNameB string
 
+// Guards the variables above, and the rest of the state of the comparison in progress, so that
+// only one comparison runs at a time. See beginComparison.
+var comparisonMu sync.Mutex
+
 type node struct {
 	msg      string
 	leftPos  token.Pos
@@ -136,11 +141,18 @@ func compareBools(a bool, b bool) (int, *node) {
 	return 0, nil
 }
 
-func compareStrings(a string, b string) (int, *node) {
+// Replace occurrences of the second package's name with the first package's name so that
+// references to the packages themselves compare as equal.
+func normalizePackageName(s string) string {
 	if equivalentPackageNameA != "" && equivalentPackageNameB != "" {
-		a = strings.ReplaceAll(a, equivalentPackageNameB, equivalentPackageNameA)
-		b = strings.ReplaceAll(b, equivalentPackageNameB, equivalentPackageNameA)
+		return strings.ReplaceAll(s, equivalentPackageNameB, equivalentPackageNameA)
 	}
+	return s
+}
+
+func compareStrings(a string, b string) (int, *node) {
+	a = normalizePackageName(a)
+	b = normalizePackageName(b)
 
 	if a < b {
 		return -1, newNode(fmt.Sprintf("strings did not match: %s < %s", a, b), nil, nil, nil)
@@ -160,6 +172,12 @@ func compareIdentifiers(a *ast.Ident, b *ast.Ident) (int, *node) {
 		return newNilRetVal(a, b, "identifiers did not match")
 	}
 
+	if typeChecked() {
+		if cmp, child, ok := compareResolvedIdentifiers(a, b); ok {
+			return newRetVal(cmp, "identifiers did not match", nil, nil, []*node{child})
+		}
+	}
+
 	cmp, child := compareStrings(a.Name, b.Name)
 	// TODO: (kevinb) should .Object be compared
 	return newRetVal(cmp, "identifiers did not match", nil, nil, []*node{child})
@@ -173,7 +191,9 @@ func compareImportSpecs(a *ast.ImportSpec, b *ast.ImportSpec) (int, *node) {
 	retCmp := 0
 	var children []*node
 
-	if cmp, child := compareIdentifiers(a.Name, b.Name); cmp != 0 {
+	if typeChecked() && importNamesEquivalent(a, b) {
+		// Only the imported package matters, not the name it was imported under.
+	} else if cmp, child := compareIdentifiers(a.Name, b.Name); cmp != 0 {
 		setIfUnset(&retCmp, cmp)
 		children = append(children, newNode("names did not match", a.Name, b.Name, &[]*node{child}))
 	}
@@ -291,6 +311,12 @@ func compareExpressions(a ast.Expr, b ast.Expr) (int, *node) {
 		return newNilRetVal(a, b, "expressions did not match")
 	}
 
+	if typeChecked() {
+		if cmp, child, ok := compareTypedExpressions(a, b); ok {
+			return newRetVal(cmp, "expressions did not match", a, b, []*node{child})
+		}
+	}
+
 	if cmp, child := compareInts(sortIndexForExpressionType(a), sortIndexForExpressionType(b)); cmp != 0 {
 		return newRetVal(
 			cmp,
@@ -1873,11 +1899,15 @@ func compareFiles(a *ast.File, b *ast.File) (int, *node) {
 		children = append(children, newNode("imports did not match", nil, nil, &[]*node{child}))
 	}
 
-	sortIdentifierList(&a.Unresolved, true)
-	sortIdentifierList(&b.Unresolved, true)
-	if cmp, child := compareIdentifierLists(a.Unresolved, b.Unresolved); cmp != 0 {
-		setIfUnset(&retCmp, cmp)
-		children = append(children, newNode("unresolved identifiers did not match", nil, nil, &[]*node{child}))
+	// Which identifiers the parser left unresolved is a parser detail, superseded by the resolved
+	// objects compared in type-checked mode.
+	if !typeChecked() {
+		sortIdentifierList(&a.Unresolved, true)
+		sortIdentifierList(&b.Unresolved, true)
+		if cmp, child := compareIdentifierLists(a.Unresolved, b.Unresolved); cmp != 0 {
+			setIfUnset(&retCmp, cmp)
+			children = append(children, newNode("unresolved identifiers did not match", nil, nil, &[]*node{child}))
+		}
 	}
 
 	return newRetVal(retCmp, "files did not match", a, b, children)
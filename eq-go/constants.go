@@ -0,0 +1,325 @@
+package eqgo
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Helpers to evaluate constant expressions and rewrite literals into a canonical spelling, so that
+// literals can be compared by value rather than by how they were written.
+//
+// Only expressions made up entirely of literals are evaluated, since identifiers may refer to
+// anything without type information. Constant expressions of kinds which have no literal spelling
+// (e.g. booleans, or floats which have no finite decimal representation) are left untouched.
+
+// Names of the predeclared types in which untyped rune, integer and float constants can be used
+// interchangeably, mapped to the literal kind they are normalized to.
+var numericTypeLiteralKinds = map[string]token.Token{
+	"byte":       token.INT,
+	"rune":       token.INT,
+	"int":        token.INT,
+	"int8":       token.INT,
+	"int16":      token.INT,
+	"int32":      token.INT,
+	"int64":      token.INT,
+	"uint":       token.INT,
+	"uint8":      token.INT,
+	"uint16":     token.INT,
+	"uint32":     token.INT,
+	"uint64":     token.INT,
+	"uintptr":    token.INT,
+	"float32":    token.FLOAT,
+	"float64":    token.FLOAT,
+	"complex64":  token.FLOAT,
+	"complex128": token.FLOAT,
+}
+
+func foldConstants(f *ast.File) {
+	rewriteExpressions(f, func(x ast.Expr, parent ast.Node) ast.Expr {
+		if v, kind, ok := evaluateConstant(x); ok {
+			if lit, ok := constantExpression(v, kind, x); ok {
+				x = lit
+			}
+		}
+
+		// Untyped constants take on the type of their context, which makes the literal's own kind
+		// irrelevant where that type is spelled out.
+		if kind, ok := typedContextLiteralKind(parent); ok {
+			x = convertLiteralKind(x, kind)
+		}
+
+		return x
+	})
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		if field, ok := n.(*ast.Field); ok && field.Tag != nil {
+			normalizeStringLiteral(field.Tag)
+		}
+		return true
+	})
+}
+
+// Evaluate x if it is a constant expression made up entirely of literals. Returns the value and the
+// kind of literal (token.INT, token.CHAR, token.FLOAT, token.IMAG or token.STRING) of the result.
+func evaluateConstant(x ast.Expr) (constant.Value, token.Token, bool) {
+	switch x := x.(type) {
+	case *ast.BasicLit:
+		v := constant.MakeFromLiteral(x.Value, x.Kind, 0)
+		if v.Kind() == constant.Unknown {
+			return nil, token.ILLEGAL, false
+		}
+		return v, x.Kind, true
+
+	case *ast.ParenExpr:
+		return evaluateConstant(x.X)
+
+	case *ast.UnaryExpr:
+		v, kind, ok := evaluateConstant(x.X)
+		if !ok {
+			return nil, token.ILLEGAL, false
+		}
+		switch {
+		case (x.Op == token.ADD || x.Op == token.SUB) && kind != token.STRING:
+		case x.Op == token.XOR && (kind == token.INT || kind == token.CHAR):
+		default:
+			return nil, token.ILLEGAL, false
+		}
+		return constant.UnaryOp(x.Op, v, 0), kind, true
+
+	case *ast.BinaryExpr:
+		vx, kindX, ok := evaluateConstant(x.X)
+		if !ok {
+			return nil, token.ILLEGAL, false
+		}
+		vy, kindY, ok := evaluateConstant(x.Y)
+		if !ok {
+			return nil, token.ILLEGAL, false
+		}
+		return evaluateBinaryConstant(x.Op, vx, kindX, vy, kindY)
+	}
+
+	return nil, token.ILLEGAL, false
+}
+
+func evaluateBinaryConstant(op token.Token, x constant.Value, kindX token.Token, y constant.Value, kindY token.Token) (constant.Value, token.Token, bool) {
+	isInteger := func(kind token.Token) bool {
+		return kind == token.INT || kind == token.CHAR
+	}
+
+	switch op {
+	case token.SHL, token.SHR:
+		if !isInteger(kindX) || !isInteger(kindY) {
+			return nil, token.ILLEGAL, false
+		}
+		s, exact := constant.Uint64Val(y)
+		if !exact || s > 1<<16 {
+			return nil, token.ILLEGAL, false
+		}
+		return constant.Shift(x, op, uint(s)), kindX, true
+
+	case token.ADD:
+		if (kindX == token.STRING) != (kindY == token.STRING) {
+			return nil, token.ILLEGAL, false
+		}
+
+	case token.SUB, token.MUL, token.QUO:
+		if kindX == token.STRING || kindY == token.STRING {
+			return nil, token.ILLEGAL, false
+		}
+
+	case token.REM, token.AND, token.OR, token.XOR, token.AND_NOT:
+		if !isInteger(kindX) || !isInteger(kindY) {
+			return nil, token.ILLEGAL, false
+		}
+
+	default:
+		// Comparisons and logical operators produce booleans, which have no literal spelling.
+		return nil, token.ILLEGAL, false
+	}
+
+	kind := constantKindRank(kindX, kindY)
+	if kind == token.IMAG {
+		return nil, token.ILLEGAL, false
+	}
+
+	if op == token.QUO || op == token.REM {
+		if constant.Sign(y) == 0 {
+			return nil, token.ILLEGAL, false
+		}
+		if isInteger(kind) && op == token.QUO {
+			op = token.QUO_ASSIGN // force integer division
+		}
+	}
+
+	return constant.BinaryOp(x, op, y), kind, true
+}
+
+// Kind of the result of a binary operation on untyped constants of the given kinds: whichever kind
+// appears later in the list integer, rune, floating-point, complex.
+func constantKindRank(a token.Token, b token.Token) token.Token {
+	rank := map[token.Token]int{
+		token.STRING: 0,
+		token.INT:    1,
+		token.CHAR:   2,
+		token.FLOAT:  3,
+		token.IMAG:   4,
+	}
+	if rank[a] >= rank[b] {
+		return a
+	}
+	return b
+}
+
+// Construct the canonical expression for a constant of the given kind. Negative numbers are
+// represented as a unary minus applied to a literal, as they would be in source.
+func constantExpression(v constant.Value, kind token.Token, orig ast.Expr) (ast.Expr, bool) {
+	if kind != token.STRING && constant.Sign(v) < 0 {
+		x, ok := constantExpression(constant.UnaryOp(token.SUB, v, 0), kind, orig)
+		if !ok {
+			return nil, false
+		}
+		return &ast.UnaryExpr{OpPos: orig.Pos(), Op: token.SUB, X: x}, true
+	}
+
+	var value string
+	switch kind {
+	case token.STRING:
+		value = strconv.Quote(constant.StringVal(v))
+	case token.INT:
+		value = v.ExactString()
+	case token.CHAR:
+		r, exact := constant.Int64Val(v)
+		if !exact || r > utf8.MaxRune {
+			return nil, false
+		}
+		value = strconv.QuoteRune(rune(r))
+	case token.FLOAT:
+		s, ok := decimalString(v)
+		if !ok {
+			return nil, false
+		}
+		value = s
+	default:
+		return nil, false
+	}
+
+	return &ast.BasicLit{ValuePos: orig.Pos(), Kind: kind, Value: value}, true
+}
+
+// Render a non-negative rational constant as a decimal floating-point literal, if it has a finite
+// decimal representation.
+func decimalString(v constant.Value) (string, bool) {
+	num, ok := new(big.Int).SetString(constant.Num(constant.ToFloat(v)).ExactString(), 10)
+	if !ok {
+		return "", false
+	}
+	den, ok := new(big.Int).SetString(constant.Denom(constant.ToFloat(v)).ExactString(), 10)
+	if !ok {
+		return "", false
+	}
+
+	// The denominator must be of the form 2^m * 5^n.
+	scale := 0
+	rest := new(big.Int).Set(den)
+	for _, p := range []int64{2, 5} {
+		bp := big.NewInt(p)
+		m := new(big.Int)
+		count := 0
+		for {
+			q, r := new(big.Int).QuoRem(rest, bp, m)
+			if r.Sign() != 0 {
+				break
+			}
+			rest = q
+			count++
+		}
+		if count > scale {
+			scale = count
+		}
+	}
+	if rest.Cmp(big.NewInt(1)) != 0 {
+		return "", false
+	}
+
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	digits := new(big.Int).Mul(num, new(big.Int).Quo(pow, den)).String()
+	for len(digits) <= scale {
+		digits = "0" + digits
+	}
+
+	intPart := digits[:len(digits)-scale]
+	fracPart := strings.TrimRight(digits[len(digits)-scale:], "0")
+	if fracPart == "" {
+		fracPart = "0"
+	}
+	return intPart + "." + fracPart, true
+}
+
+// If parent spells out a numeric type for the constants it holds (a typed var/const spec, or a
+// conversion to a predeclared type), return the literal kind those constants are normalized to.
+func typedContextLiteralKind(parent ast.Node) (token.Token, bool) {
+	var typ ast.Expr
+	switch p := parent.(type) {
+	case *ast.ValueSpec:
+		typ = p.Type
+	case *ast.CallExpr:
+		if len(p.Args) != 1 {
+			return token.ILLEGAL, false
+		}
+		typ = p.Fun
+	default:
+		return token.ILLEGAL, false
+	}
+
+	ident, ok := typ.(*ast.Ident)
+	if !ok {
+		return token.ILLEGAL, false
+	}
+	kind, ok := numericTypeLiteralKinds[ident.Name]
+	return kind, ok
+}
+
+// Rewrite a numeric literal (or negated numeric literal) as the given kind of literal.
+func convertLiteralKind(x ast.Expr, kind token.Token) ast.Expr {
+	if unary, ok := x.(*ast.UnaryExpr); ok && unary.Op == token.SUB {
+		unary.X = convertLiteralKind(unary.X, kind)
+		return x
+	}
+
+	lit, ok := x.(*ast.BasicLit)
+	if !ok || lit.Kind == kind || lit.Kind == token.STRING || lit.Kind == token.IMAG {
+		return x
+	}
+
+	v := constant.MakeFromLiteral(lit.Value, lit.Kind, 0)
+	if v.Kind() == constant.Unknown {
+		return x
+	}
+	if kind == token.INT {
+		if v = constant.ToInt(v); v.Kind() != constant.Int {
+			return x
+		}
+	}
+
+	if converted, ok := constantExpression(v, kind, lit); ok {
+		return converted
+	}
+	return x
+}
+
+// Rewrite a string literal in place into its canonical interpreted (double-quoted) spelling.
+func normalizeStringLiteral(lit *ast.BasicLit) {
+	if lit.Kind != token.STRING {
+		return
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return
+	}
+	lit.Value = strconv.Quote(s)
+}
@@ -0,0 +1,58 @@
+package eqgo
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestFoldConstants(t *testing.T) {
+	testCases := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{a: "var x = 0x10", b: "var x = 16", want: true},
+		{a: "var x = 1_000", b: "var x = 1000", want: true},
+		{a: "var x = 0o17", b: "var x = 15", want: true},
+		{a: "var x = 1<<3", b: "var x = 8", want: true},
+		{a: "var x = (2 + 3) * 4", b: "var x = 20", want: true},
+		{a: "var x = 7 / 2", b: "var x = 3", want: true},
+		{a: "var x = 7 / 2.0", b: "var x = 3.5", want: true},
+		{a: "var x = 1e3", b: "var x = 1000.0", want: true},
+		{a: "var x = .1", b: "var x = 0.10", want: true},
+		{a: "var x = -0x1", b: "var x = -1", want: true},
+		{a: "var x = ^0", b: "var x = -1", want: true},
+		{a: "var x = `raw`", b: "var x = \"raw\"", want: true},
+		{a: "var x = \"a\" + \"b\" + `c`", b: "var x = \"abc\"", want: true},
+		{a: "var x = '\\x61'", b: "var x = 'a'", want: true},
+		{a: "var x rune = 'a'", b: "var x rune = 97", want: true},
+		{a: "var x = rune('a')", b: "var x = rune(97)", want: true},
+		{a: "var x float64 = 1", b: "var x float64 = 1.0", want: true},
+		{a: "type T struct { X int `json:\"x\"` }", b: "type T struct { X int \"json:\\\"x\\\"\" }", want: true},
+		{a: "var x = 1e3", b: "var x = 1000", want: false},
+		{a: "var x = 'a'", b: "var x = 97", want: false},
+		{a: "var x = 16", b: "var x = 17", want: false},
+		{a: "var x = 1 / 0", b: "var x = 1 / 0", want: true},
+		{a: "func f() int { return 2 * 8 }", b: "func f() int { return 0x10 }", want: true},
+	}
+	for _, c := range testCases {
+		fset := token.NewFileSet()
+		fileA, err := parser.ParseFile(fset, "a.go", "package p; "+c.a, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileB, err := parser.ParseFile(fset, "b.go", "package p; "+c.b, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := CompareFiles(fileA, fset, fileB, fset, &Options{FoldConstants: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Equivalent != c.want {
+			t.Errorf("CompareFiles(%q, %q) == %t, want %t\n%s", c.a, c.b, got.Equivalent, c.want, got.Format(nil))
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package eqgo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// Helpers to break a file down into its individual top-level declarations so that declarations can
+// be matched up by name between two packages.
+
+type declaration struct {
+	// Identifies the declaration within its package, e.g. "func Foo", "method T.Bar" or "type T".
+	key  string
+	decl ast.Decl
+}
+
+// Split the top-level declarations of a file into one declaration per import, function, method,
+// type, var or const spec. The returned list is sorted by key.
+func collectDeclarations(f *ast.File) []declaration {
+	var decls []declaration
+	seen := make(map[string]int)
+
+	add := func(key string, d ast.Decl) {
+		seen[key]++
+		key = numberedDeclarationKey(key, seen[key]-1)
+		decls = append(decls, declaration{key: key, decl: d})
+	}
+
+	for _, s := range f.Imports {
+		add("import "+s.Path.Value, &ast.GenDecl{
+			TokPos: s.Pos(),
+			Tok:    token.IMPORT,
+			Specs:  []ast.Spec{s},
+		})
+	}
+
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			add(funcDeclKey(d), d)
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			for _, s := range d.Specs {
+				if _, ok := s.(*ast.ImportSpec); ok {
+					continue
+				}
+				add(specKey(d.Tok, s), &ast.GenDecl{
+					Doc:    d.Doc,
+					TokPos: d.TokPos,
+					Tok:    d.Tok,
+					Specs:  []ast.Spec{s},
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(decls, func(i, j int) bool {
+		return decls[i].key < decls[j].key
+	})
+	return decls
+}
+
+func funcDeclKey(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return "func " + d.Name.Name
+	}
+	return fmt.Sprintf("method %s.%s", receiverTypeName(d.Recv.List[0].Type), d.Name.Name)
+}
+
+// Name of the base type of a method receiver, ignoring pointer indirection and parentheses.
+func receiverTypeName(x ast.Expr) string {
+	for {
+		switch t := x.(type) {
+		case *ast.StarExpr:
+			x = t.X
+		case *ast.ParenExpr:
+			x = t.X
+		case *ast.IndexExpr:
+			x = t.X
+		case *ast.IndexListExpr:
+			x = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return "?"
+		}
+	}
+}
+
+func specKey(tok token.Token, s ast.Spec) string {
+	switch s := s.(type) {
+	case *ast.TypeSpec:
+		return "type " + s.Name.Name
+	case *ast.ValueSpec:
+		var names []string
+		for _, n := range s.Names {
+			names = append(names, n.Name)
+		}
+		return fmt.Sprintf("%s %s", tok, strings.Join(names, ", "))
+	}
+	return tok.String()
+}
+
+// A top-level declaration matched up between two sides. Either side may be nil if the declaration
+// only exists on the other side.
+type declarationPair struct {
+	key         string
+	left, right *declaration
+}
+
+// Pair up two declaration lists by key, sorted by key, with the names of the packages being
+// compared treated as equivalent. Declarations with the same name (e.g. several init functions) are
+// keyed "init", "init#2", etc. in source order, so are paired up by equivalence rather than by
+// position, and only the remaining ones are paired in order. Each pair is then keyed by its
+// position among the pairs with the same name.
+func matchDeclarations(a []declaration, b []declaration) []declarationPair {
+	groupsA, groupsB := groupDeclarations(a), groupDeclarations(b)
+
+	var pairs []declarationPair
+	for _, k := range groupKeys(groupsA, groupsB) {
+		key := groupDisplayKey(k, groupsA, groupsB)
+		for i, pair := range pairEquivalentDeclarations(groupsA[k], groupsB[k]) {
+			pairs = append(pairs, declarationPair{key: numberedDeclarationKey(key, i), left: pair[0], right: pair[1]})
+		}
+	}
+	return pairs
+}
+
+// The keys of all of the groups, sorted by their display keys.
+func groupKeys(groups ...map[string][]*declaration) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, g := range groups {
+		for k := range g {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return groupDisplayKey(keys[i], groups...) < groupDisplayKey(keys[j], groups...)
+	})
+	return keys
+}
+
+// Key to report the declarations grouped under k by: the key of the first of them found in
+// groups, as written in its source rather than with package names normalized.
+func groupDisplayKey(k string, groups ...map[string][]*declaration) string {
+	for _, g := range groups {
+		if decls := g[k]; len(decls) > 0 {
+			return baseDeclarationKey(decls[0].key)
+		}
+	}
+	return k
+}
+
+// Pair up two lists of declarations with the same name, pairing equivalent declarations first and
+// the remaining ones in order. Pairs are ordered by their declaration from a, followed by those
+// only found in b. Either element of a pair may be nil if there are more declarations on the other
+// side.
+func pairEquivalentDeclarations(a []*declaration, b []*declaration) [][2]*declaration {
+	matched := make([]*declaration, len(a))
+	used := make([]bool, len(b))
+	if len(a) > 1 || len(b) > 1 {
+		for i := range a {
+			for j := range b {
+				if used[j] {
+					continue
+				}
+				if cmp, _ := compareDecls(a[i].decl, b[j].decl); cmp == 0 {
+					matched[i] = b[j]
+					used[j] = true
+					break
+				}
+			}
+		}
+	}
+
+	next := 0
+	nextUnused := func() *declaration {
+		for ; next < len(b); next++ {
+			if !used[next] {
+				used[next] = true
+				return b[next]
+			}
+		}
+		return nil
+	}
+
+	var pairs [][2]*declaration
+	for i := range a {
+		if matched[i] == nil {
+			matched[i] = nextUnused()
+		}
+		pairs = append(pairs, [2]*declaration{a[i], matched[i]})
+	}
+	for d := nextUnused(); d != nil; d = nextUnused() {
+		pairs = append(pairs, [2]*declaration{nil, d})
+	}
+	return pairs
+}
+
+// Key of the i-th (from 0) declaration with the given base key, as in collectDeclarations.
+func numberedDeclarationKey(key string, i int) string {
+	if i == 0 {
+		return key
+	}
+	return fmt.Sprintf("%s#%d", key, i+1)
+}
+
+// Group declarations by key, ignoring the suffix which tells apart declarations with the same name,
+// with the names of the packages being compared treated as equivalent.
+func groupDeclarations(decls []declaration) map[string][]*declaration {
+	groups := make(map[string][]*declaration)
+	for i := range decls {
+		k := normalizePackageName(baseDeclarationKey(decls[i].key))
+		groups[k] = append(groups[k], &decls[i])
+	}
+	return groups
+}
+
+// Sort the contents of the top-level declarations of f in place, as compareFiles does before
+// comparing them, so that declarations compared one at a time are compared in the same canonical
+// form. The order of f.Decls itself is left unchanged.
+func sortDeclarations(f *ast.File) {
+	_, genDecls, funcDecls := splitDecls(f.Decls)
+	sortGenDeclList(&genDecls)
+	sortFuncDeclList(&funcDecls)
+	sortImportList(&f.Imports)
+}
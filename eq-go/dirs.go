@@ -0,0 +1,163 @@
+package eqgo
+
+import (
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kevinmbeaulieu/eq-go/eq-go/loader"
+)
+
+// DirsResult describes the outcome of comparing two directory trees of Go packages.
+type DirsResult struct {
+	// Whether both trees contain the same packages and every pair of packages is equivalent.
+	Equivalent bool
+
+	// Result of comparing each package found in both trees, sorted by path.
+	Packages []PackageResult
+
+	// Paths of packages found in only one of the trees, sorted.
+	LeftOnly, RightOnly []string
+}
+
+// PackageResult describes the outcome of comparing one pair of packages found by CompareDirs.
+type PackageResult struct {
+	// Slash-separated path of the package's directory relative to the root of its tree, or "." for
+	// the root itself.
+	Path string
+
+	Result *Result
+}
+
+// Format returns a message describing the differences found in each package. If f is nil, a
+// DefaultFormatter is used for each package.
+func (r *DirsResult) Format(f Formatter) string {
+	if r.Equivalent {
+		return "equivalent"
+	}
+
+	var builder strings.Builder
+	fmt.Fprint(&builder, "not equivalent:")
+	for _, path := range r.LeftOnly {
+		fmt.Fprintf(&builder, "\npackage %s only exists on the left", path)
+	}
+	for _, path := range r.RightOnly {
+		fmt.Fprintf(&builder, "\npackage %s only exists on the right", path)
+	}
+	for _, p := range r.Packages {
+		if p.Result.Equivalent {
+			continue
+		}
+		fmt.Fprintf(&builder, "\npackage %s: %s", p.Path, p.Result.Format(f))
+	}
+	return builder.String()
+}
+
+// CompareDirs walks the directory trees rooted at leftRoot and rightRoot, pairs up the Go packages
+// found in them by their path relative to the root, and compares each pair as for ComparePackages
+// with opts. Packages found in only one of the trees are reported, and make the trees
+// non-equivalent.
+//
+// Packages are loaded with loader.Load, so files are selected by build constraints for the host
+// platform. As with the go command, directories named testdata or vendor, or whose names begin
+// with "." or "_", are skipped. Test files are ignored unless opts.IncludeTests is set.
+//
+// opts may be nil.
+func CompareDirs(leftRoot string, rightRoot string, opts *Options) (*DirsResult, error) {
+	left, err := loadPackageDirs(leftRoot, opts)
+	if err != nil {
+		return nil, err
+	}
+	right, err := loadPackageDirs(rightRoot, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &DirsResult{}
+	for _, path := range sortedPackagePaths(left) {
+		b, ok := right[path]
+		if !ok {
+			r.LeftOnly = append(r.LeftOnly, path)
+			continue
+		}
+		a := left[path]
+
+		result, err := CompareLoadedPackages(a, b, opts)
+		if err != nil {
+			return nil, fmt.Errorf("comparing package %s: %v", path, err)
+		}
+		r.Packages = append(r.Packages, PackageResult{Path: path, Result: result})
+	}
+	for _, path := range sortedPackagePaths(right) {
+		if _, ok := left[path]; !ok {
+			r.RightOnly = append(r.RightOnly, path)
+		}
+	}
+
+	r.Equivalent = len(r.LeftOnly) == 0 && len(r.RightOnly) == 0
+	for _, p := range r.Packages {
+		if !p.Result.Equivalent {
+			r.Equivalent = false
+		}
+	}
+
+	return r, nil
+}
+
+// Load the packages of every directory below root which has a library package, or any Go files if
+// opts includes tests, keyed by the directory's slash-separated path relative to root.
+func loadPackageDirs(root string, opts *Options) (map[string]*loader.Packages, error) {
+	pkgs := make(map[string]*loader.Packages)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+
+		if path != root && skipPackageDir(info.Name()) {
+			return filepath.SkipDir
+		}
+
+		p, err := loader.Load(path)
+		if _, ok := err.(*build.NoGoError); ok {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(p.Files) == 0 && (opts == nil || !opts.IncludeTests) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		pkgs[filepath.ToSlash(rel)] = p
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pkgs, nil
+}
+
+// Whether a directory with the given name is ignored when looking for packages, as by the go
+// command.
+func skipPackageDir(name string) bool {
+	return name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+func sortedPackagePaths(pkgs map[string]*loader.Packages) []string {
+	var paths []string
+	for path := range pkgs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
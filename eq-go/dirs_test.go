@@ -0,0 +1,79 @@
+package eqgo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root, err := ioutil.TempDir("", "eqgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range files {
+		filename := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestCompareDirs(t *testing.T) {
+	left := writeTree(t, map[string]string{
+		"root.go":           "package root\nfunc F() {}\n",
+		"a/a.go":            "package a\nfunc A() int { return 1 }\nfunc B() {}\n",
+		"b/b.go":            "package b\nfunc B() int { return 1 }\n",
+		"only/left.go":      "package only\n",
+		"testdata/x/x.go":   "package x\n",
+		"a/a_test.go":       "package a\nfunc TestA() {}\n",
+		"c/c.go":            "package c\nvar C = 1\n",
+		"c/nested/n.go":     "package nested\nvar N = 1\n",
+		".hidden/hidden.go": "package hidden\n",
+	})
+	defer os.RemoveAll(left)
+
+	right := writeTree(t, map[string]string{
+		"root.go":       "package root\nfunc F() {}\n",
+		"a/a1.go":       "package a\nfunc B() {}\n",
+		"a/a2.go":       "package a\nfunc A() int { return 1 }\n",
+		"b/b.go":        "package b\nfunc B() int { return 2 }\n",
+		"only/right.go": "package only\n",
+		"c/c.go":        "package c\nvar C = 1\n",
+		"new/new.go":    "package new\n",
+	})
+	defer os.RemoveAll(right)
+
+	got, err := CompareDirs(left, right, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Equivalent {
+		t.Errorf("CompareDirs().Equivalent == true, want false")
+	}
+	if want := []string{"c/nested"}; !reflect.DeepEqual(got.LeftOnly, want) {
+		t.Errorf("CompareDirs().LeftOnly == %v, want %v", got.LeftOnly, want)
+	}
+	if want := []string{"new"}; !reflect.DeepEqual(got.RightOnly, want) {
+		t.Errorf("CompareDirs().RightOnly == %v, want %v", got.RightOnly, want)
+	}
+
+	want := map[string]bool{".": true, "a": true, "b": false, "c": true, "only": true}
+	if len(got.Packages) != len(want) {
+		t.Errorf("CompareDirs() compared %d packages, want %d", len(got.Packages), len(want))
+	}
+	for _, p := range got.Packages {
+		if p.Result.Equivalent != want[p.Path] {
+			t.Errorf("package %s: Equivalent == %t, want %t\n%s", p.Path, p.Result.Equivalent, want[p.Path], p.Result.Format(nil))
+		}
+	}
+}
@@ -4,8 +4,79 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"sort"
 )
 
+// Result describes the outcome of comparing two packages or files.
+type Result struct {
+	// Whether the inputs are equivalent.
+	Equivalent bool
+
+	// Normalized tree-edit-distance similarity of the inputs, from 0 (nothing in common) to 1
+	// (equivalent).
+	Similarity float64
+
+	// Similarity of each top-level declaration found on either side, sorted by key.
+	Declarations []DeclarationResult
+
+	// In subset mode, the declarations which only exist on the right, sorted by key. Only Key and
+	// RightPos are set. Additions are informational and don't affect Equivalent.
+	Additions []DeclarationResult
+
+	// When comparing with tests included, the result of comparing the external test packages, or
+	// nil if neither side has one. Equivalent takes it into account.
+	ExternalTest *Result
+
+	// With Options.ReportLayout, how the declarations found on both sides are laid out across
+	// files. Nil otherwise.
+	Layout *LayoutResult
+
+	root                *node
+	leftFSet, rightFSet *token.FileSet
+}
+
+// DeclarationResult describes how a single top-level declaration compares between two inputs.
+type DeclarationResult struct {
+	// Identifies the declaration within its package, e.g. "func Foo", "method T.Bar", "type T",
+	// "var x" or `import "fmt"`.
+	Key string
+
+	// Position of the declaration on each side. A position is invalid if the declaration does not
+	// exist on that side.
+	LeftPos, RightPos token.Position
+
+	// Normalized tree-edit-distance similarity of the two declarations, from 0 to 1.
+	Similarity float64
+}
+
+// Format returns a message describing the differences found. If f is nil, a DefaultFormatter is
+// used.
+func (r *Result) Format(f Formatter) string {
+	if f == nil {
+		f = DefaultFormatter{
+			LeftFSet:  r.leftFSet,
+			RightFSet: r.rightFSet,
+		}
+	}
+	s := f.Format(r.root == nil, r.root)
+	if r.ExternalTest != nil && !r.ExternalTest.Equivalent {
+		s += "\n\nexternal test package " + r.ExternalTest.Format(f)
+	}
+	return s
+}
+
+// Record a difference found separately from the comparison of the files' declarations, making r
+// non-equivalent. msg describes the new root of the tree of differences.
+func (r *Result) addDifference(msg string, n *node) {
+	var children []*node
+	if r.root != nil {
+		children = append(children, r.root)
+	}
+	children = append(children, n)
+	r.root = newNode(msg, nil, nil, &children)
+	r.Equivalent = false
+}
+
 // PackagesEquivalent reports whether the Go packages represented by a and b are equivalent.
 // Packages are equivalent if their sets of declarations are invariant under reordering,
 // adding/removing spacing/indentation, and adding/removing comments.
@@ -21,27 +92,83 @@ import (
 //     A message describing any differences found
 // )
 func PackagesEquivalent(a *ast.Package, fsetA *token.FileSet, b *ast.Package, fsetB *token.FileSet, f Formatter) (bool, string) {
+	r, err := comparePackages(a, fsetA, b, fsetB, nil, false)
+	if err != nil {
+		panic(err)
+	}
+	return r.Equivalent, r.Format(f)
+}
+
+// ComparePackages compares the Go packages represented by a and b, using the same notion of
+// equivalence as PackagesEquivalent relaxed by any normalizations enabled in opts, and additionally
+// measures how similar the packages and each of their top-level declarations are. It is equivalent
+// to ComparePackageFiles with the files of a and b.
+//
+// opts may be nil.
+//
+// Deprecated: ast.Package is deprecated; use ComparePackageFiles.
+func ComparePackages(a *ast.Package, fsetA *token.FileSet, b *ast.Package, fsetB *token.FileSet, opts *Options) (*Result, error) {
+	return comparePackages(a, fsetA, b, fsetB, opts, true)
+}
+
+func comparePackages(a *ast.Package, fsetA *token.FileSet, b *ast.Package, fsetB *token.FileSet, opts *Options, measureSimilarity bool) (*Result, error) {
 	if a == nil || b == nil {
-		panic(fmt.Errorf("missing package"))
+		return nil, fmt.Errorf("missing package")
 	}
 
-	equivalentPackageNameA = a.Name
-	equivalentPackageNameB = b.Name
+	return comparePackageFiles(a.Name, packageFiles(a), fsetA, b.Name, packageFiles(b), fsetB, opts, measureSimilarity)
+}
 
-	mergeMode := ast.FilterUnassociatedComments | ast.FilterImportDuplicates
-	mergedFileA := ast.MergePackageFiles(a, mergeMode)
-	mergedFileB := ast.MergePackageFiles(b, mergeMode)
+// ComparePackageFiles compares the Go packages made up of the files in a and b, using the same
+// notion of equivalence as PackagesEquivalent relaxed by any normalizations enabled in opts, and
+// additionally measures how similar the packages and each of their top-level declarations are.
+// Every file must belong to the same package as the others on its side.
+//
+// Files are not merged with ast.MergePackageFiles: each declaration and import is compared as it
+// appears in its own file, so imports of the same path under different names in different files
+// are kept apart, and the position of every difference, including its file name, refers to the
+// file it came from.
+//
+// opts may be nil.
+func ComparePackageFiles(a []*ast.File, fsetA *token.FileSet, b []*ast.File, fsetB *token.FileSet, opts *Options) (*Result, error) {
+	nameA, err := filesPackageName(a, fsetA)
+	if err != nil {
+		return nil, err
+	}
+	nameB, err := filesPackageName(b, fsetB)
+	if err != nil {
+		return nil, err
+	}
+
+	return comparePackageFiles(nameA, sortedFiles(a, fsetA), fsetA, nameB, sortedFiles(b, fsetB), fsetB, opts, true)
+}
 
-	cmp, root := compareFiles(mergedFileA, mergedFileB)
-	eq := cmp == 0
+func comparePackageFiles(nameA string, a []*ast.File, fsetA *token.FileSet, nameB string, b []*ast.File, fsetB *token.FileSet, opts *Options, measureSimilarity bool) (*Result, error) {
+	defer beginComparison(nameA, nameB)()
 
-	if f == nil {
-		f = DefaultFormatter{
-			LeftFSet:  fsetA,
-			RightFSet: fsetB,
+	// Initialization order depends on the order of declarations, so must be found before
+	// normalization reorders them.
+	var initCmp int
+	var initDiff *node
+	if opts != nil && opts.InitOrder {
+		var err error
+		if initCmp, initDiff, err = compareInitOrder(nameA, a, fsetA, nameB, b, fsetB); err != nil {
+			return nil, err
 		}
 	}
-	return eq, f.Format(eq, root)
+
+	if opts != nil && opts.TypeCheck {
+		if err := startTypeCheckedComparison(nameA, a, fsetA, nameB, b, fsetB); err != nil {
+			return nil, err
+		}
+		defer endTypeCheckedComparison()
+	}
+
+	r := compare(mergeFiles(nameA, a), fsetA, mergeFiles(nameB, b), fsetB, opts, measureSimilarity)
+	if initCmp != 0 {
+		r.addDifference("packages did not match", initDiff)
+	}
+	return r, nil
 }
 
 // FilesEquivalent reports whether the Go source files represented by a and b are equivalent.
@@ -59,14 +186,208 @@ func PackagesEquivalent(a *ast.Package, fsetA *token.FileSet, b *ast.Package, fs
 //     A message describing the differences found
 // )
 func FilesEquivalent(a *ast.File, fsetA *token.FileSet, b *ast.File, fsetB *token.FileSet, f Formatter) (bool, string) {
-	cmp, root := compareFiles(a, b)
-	eq := cmp == 0
+	r, err := compareSourceFiles(a, fsetA, b, fsetB, nil, false)
+	if err != nil {
+		panic(err)
+	}
+	return r.Equivalent, r.Format(f)
+}
 
-	if f == nil {
-		f = DefaultFormatter{
-			LeftFSet:  fsetA,
-			RightFSet: fsetB,
+// CompareFiles compares the Go source files represented by a and b, using the same notion of
+// equivalence as FilesEquivalent relaxed by any normalizations enabled in opts, and additionally
+// measures how similar the files and each of their top-level declarations are.
+//
+// opts may be nil. In type-checked mode, each file is type-checked as a package on its own.
+func CompareFiles(a *ast.File, fsetA *token.FileSet, b *ast.File, fsetB *token.FileSet, opts *Options) (*Result, error) {
+	return compareSourceFiles(a, fsetA, b, fsetB, opts, true)
+}
+
+func compareSourceFiles(a *ast.File, fsetA *token.FileSet, b *ast.File, fsetB *token.FileSet, opts *Options, measureSimilarity bool) (*Result, error) {
+	if a == nil || b == nil {
+		return nil, fmt.Errorf("missing file")
+	}
+
+	defer beginComparison("", "")()
+
+	var initCmp int
+	var initDiff *node
+	if opts != nil && opts.InitOrder {
+		var err error
+		if initCmp, initDiff, err = compareInitOrder(a.Name.Name, []*ast.File{a}, fsetA, b.Name.Name, []*ast.File{b}, fsetB); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts != nil && opts.TypeCheck {
+		if err := startTypeCheckedComparison(a.Name.Name, []*ast.File{a}, fsetA, b.Name.Name, []*ast.File{b}, fsetB); err != nil {
+			return nil, err
+		}
+		defer endTypeCheckedComparison()
+	}
+
+	r := compare(a, fsetA, b, fsetB, opts, measureSimilarity)
+	if initCmp != 0 {
+		r.addDifference("files did not match", initDiff)
+	}
+	return r, nil
+}
+
+// Files of a package, sorted by filename.
+func packageFiles(pkg *ast.Package) []*ast.File {
+	var names []string
+	for name := range pkg.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var files []*ast.File
+	for _, name := range names {
+		files = append(files, pkg.Files[name])
+	}
+	return files
+}
+
+// Start a comparison between packages named nameA and nameB, whose names are then treated as
+// equivalent, or of files or packages whose names don't matter if both are empty. Comparisons keep
+// their state in package-level variables, so only one runs at a time: the returned function ends
+// the comparison, clearing its state and letting the next one start.
+func beginComparison(nameA string, nameB string) (end func()) {
+	comparisonMu.Lock()
+	equivalentPackageNameA, equivalentPackageNameB = nameA, nameB
+	return func() {
+		equivalentPackageNameA, equivalentPackageNameB = "", ""
+		typeCheckedPackages = nil
+		clonedNodes = nil
+		comparisonMu.Unlock()
+	}
+}
+
+// Name of the package the files belong to, or an error if there are no files or they belong to
+// different packages.
+func filesPackageName(files []*ast.File, fset *token.FileSet) (string, error) {
+	if len(files) == 0 {
+		return "", fmt.Errorf("missing package")
+	}
+
+	name := files[0].Name.Name
+	for _, f := range files[1:] {
+		if f.Name.Name == name {
+			continue
+		}
+		if fset == nil {
+			return "", fmt.Errorf("found packages %s and %s", name, f.Name.Name)
+		}
+		return "", fmt.Errorf("%s: found package %s, want %s", fset.Position(f.Pos()).Filename, f.Name.Name, name)
+	}
+	return name, nil
+}
+
+// Copy of files sorted by filename.
+func sortedFiles(files []*ast.File, fset *token.FileSet) []*ast.File {
+	sorted := append([]*ast.File{}, files...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return fset.Position(sorted[i].Pos()).Filename < fset.Position(sorted[j].Pos()).Filename
+	})
+	return sorted
+}
+
+// Combine the files of a package into a single file which can be compared as a whole. Unlike
+// ast.MergePackageFiles, the declarations and import specs of each file are kept as they are, so
+// their positions still refer to the files they came from. Imports are deduplicated by name and
+// path rather than by path alone, since imports are scoped to their file.
+//
+// Like ast.MergePackageFiles, the merged file has no unresolved identifiers, since identifiers left
+// unresolved in one file may be declared in another.
+func mergeFiles(name string, files []*ast.File) *ast.File {
+	merged := &ast.File{Name: ast.NewIdent(name)}
+	if len(files) > 0 {
+		merged.Package = files[0].Package
+		merged.Name.NamePos = files[0].Name.NamePos
+	}
+
+	seen := make(map[string]bool)
+	importKey := func(s *ast.ImportSpec) string {
+		if s.Name != nil {
+			return s.Name.Name + " " + s.Path.Value
+		}
+		return s.Path.Value
+	}
+
+	for _, f := range files {
+		for _, d := range f.Decls {
+			genDecl, ok := d.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.IMPORT {
+				merged.Decls = append(merged.Decls, d)
+				continue
+			}
+
+			var specs []ast.Spec
+			for _, s := range genDecl.Specs {
+				importSpec := s.(*ast.ImportSpec)
+				if key := importKey(importSpec); !seen[key] {
+					seen[key] = true
+					specs = append(specs, s)
+					merged.Imports = append(merged.Imports, importSpec)
+				}
+			}
+			if len(specs) == 0 {
+				continue
+			}
+			if len(specs) < len(genDecl.Specs) {
+				filtered := *genDecl
+				filtered.Specs = specs
+				genDecl = &filtered
+			}
+			merged.Decls = append(merged.Decls, genDecl)
 		}
+		merged.Comments = append(merged.Comments, f.Comments...)
 	}
-	return eq, f.Format(eq, root)
+
+	return merged
+}
+
+// Compare two files, normalizing and sorting copies of them so that the files themselves are left
+// unchanged. Measuring similarity is expensive for large differences, so is only done if
+// measureSimilarity is set. Otherwise similarities are left 0 unless the files are equivalent.
+func compare(a *ast.File, fsetA *token.FileSet, b *ast.File, fsetB *token.FileSet, opts *Options, measureSimilarity bool) *Result {
+	a = cloneForComparison(a).(*ast.File)
+	b = cloneForComparison(b).(*ast.File)
+	normalizeFile(a, opts)
+	normalizeFile(b, opts)
+
+	var cmp int
+	var root *node
+	var additions []DeclarationResult
+	if opts != nil && opts.Subset {
+		cmp, root, additions = compareSubset(a, b, fsetB)
+	} else {
+		cmp, root = compareFiles(a, b)
+	}
+
+	r := &Result{
+		Equivalent: cmp == 0,
+		Additions:  additions,
+		root:       root,
+		leftFSet:   fsetA,
+		rightFSet:  fsetB,
+	}
+
+	r.Declarations, r.Similarity = declarationSimilarities(
+		matchDeclarations(collectDeclarations(a), collectDeclarations(b)),
+		fsetA,
+		fsetB,
+		measureSimilarity,
+	)
+	if r.Equivalent {
+		r.Similarity = 1
+		for i := range r.Declarations {
+			r.Declarations[i].Similarity = 1
+		}
+	}
+
+	if opts != nil && opts.ReportLayout {
+		r.Layout = compareLayout(r.Declarations)
+	}
+
+	return r
 }
@@ -0,0 +1,186 @@
+package eqgo
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Parse each source, keyed by filename, into a file of a single file set.
+func parseTestFiles(t *testing.T, sources map[string]string) ([]*ast.File, *token.FileSet) {
+	t.Helper()
+
+	var names []string
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range names {
+		f, err := parser.ParseFile(fset, name, sources[name], parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	return files, fset
+}
+
+func TestComparePackageFiles(t *testing.T) {
+	testCases := []struct {
+		name        string
+		left, right map[string]string
+		want        bool
+		wantInMsg   []string
+	}{
+		{
+			name:  "declarations moved between files",
+			left:  map[string]string{"a.go": "package p\nfunc F() {}\n", "b.go": "package p\nfunc G() {}\n"},
+			right: map[string]string{"c.go": "package p\nfunc G() {}\nfunc F() {}\n"},
+			want:  true,
+		},
+		{
+			name:  "same import in several files",
+			left:  map[string]string{"a.go": "package p\nimport \"fmt\"\nvar A = fmt.Sprint\n", "b.go": "package p\nimport \"fmt\"\nvar B = fmt.Sprint\n"},
+			right: map[string]string{"c.go": "package p\nimport \"fmt\"\nvar A = fmt.Sprint\nvar B = fmt.Sprint\n"},
+			want:  true,
+		},
+		{
+			name:  "file-scoped import names kept",
+			left:  map[string]string{"a.go": "package p\nimport f \"fmt\"\nvar A = f.Sprint\n", "b.go": "package p\nimport \"fmt\"\nvar B = fmt.Sprint\n"},
+			right: map[string]string{"c.go": "package p\nimport \"fmt\"\nvar A = fmt.Sprint\nvar B = fmt.Sprint\n"},
+			want:  false,
+		},
+		{
+			name:      "differences carry file names",
+			left:      map[string]string{"a.go": "package p\nfunc F() {}\n", "b.go": "package p\nfunc G() int { return 1 }\n"},
+			right:     map[string]string{"c.go": "package p\nfunc F() {}\n", "d.go": "package p\nfunc G() int { return 2 }\n"},
+			want:      false,
+			wantInMsg: []string{"b.go:2:", "d.go:2:"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, fsetA := parseTestFiles(t, tc.left)
+			b, fsetB := parseTestFiles(t, tc.right)
+
+			r, err := ComparePackageFiles(a, fsetA, b, fsetB, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg := r.Format(nil)
+			if r.Equivalent != tc.want {
+				t.Errorf("Equivalent = %t, want %t\n%s", r.Equivalent, tc.want, msg)
+			}
+			for _, s := range tc.wantInMsg {
+				if !strings.Contains(msg, s) {
+					t.Errorf("message does not mention %q:\n%s", s, msg)
+				}
+			}
+		})
+	}
+}
+
+func TestComparePackageFilesDeclarationKeys(t *testing.T) {
+	testCases := []struct {
+		name        string
+		left, right map[string]string
+		wantKeys    []string
+	}{
+		{
+			name:     "package names in identifiers",
+			left:     map[string]string{"a.go": "package a\nvar debug = 1\ntype aT int\n"},
+			right:    map[string]string{"b.go": "package b\nvar debug = 1\ntype bT int\n"},
+			wantKeys: []string{"type aT", "var debug"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, fsetA := parseTestFiles(t, tc.left)
+			b, fsetB := parseTestFiles(t, tc.right)
+
+			r, err := ComparePackageFiles(a, fsetA, b, fsetB, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !r.Equivalent {
+				t.Errorf("packages not equivalent:\n%s", r.Format(nil))
+			}
+
+			var keys []string
+			for _, d := range r.Declarations {
+				keys = append(keys, d.Key)
+				if d.Similarity != 1 {
+					t.Errorf("similarity of %s == %f, want 1", d.Key, d.Similarity)
+				}
+			}
+			if !equalStringSlices(keys, tc.wantKeys) {
+				t.Errorf("keys == %v, want %v", keys, tc.wantKeys)
+			}
+		})
+	}
+}
+
+func TestCollectDeclarationsGenericReceivers(t *testing.T) {
+	files, _ := parseTestFiles(t, map[string]string{"a.go": "package p\nfunc (P[K]) M() {}\nfunc (*Q[K, V]) M() {}\n"})
+
+	var keys []string
+	for _, d := range collectDeclarations(files[0]) {
+		keys = append(keys, d.key)
+	}
+	want := []string{"method P.M", "method Q.M"}
+	if !equalStringSlices(keys, want) {
+		t.Errorf("keys == %v, want %v", keys, want)
+	}
+}
+
+func TestComparePackageFilesLeavesFilesUnchanged(t *testing.T) {
+	sources := map[string]string{
+		"a.go": "package p\nvar (\n\tx, y = 1 + 2, (3)\n)\ntype I interface {\n\tB()\n\tA()\n}\n",
+		"b.go": "package p\nfunc F(b int, a string) {}\nconst (\n\tC = iota\n\tD\n)\n",
+	}
+	opts := &Options{FoldConstants: true, FlattenDeclarations: true, NormalizeParentheses: true, UnorderedInterfaceMethods: true}
+
+	print := func(files []*ast.File, fset *token.FileSet) string {
+		var buf bytes.Buffer
+		for _, f := range files {
+			if err := printer.Fprint(&buf, fset, f); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return buf.String()
+	}
+
+	a, fsetA := parseTestFiles(t, sources)
+	b, fsetB := parseTestFiles(t, sources)
+	want := print(a, fsetA)
+
+	if _, err := ComparePackageFiles(a, fsetA, b, fsetB, opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CompareThreeWay(a, fsetA, b, fsetB, b, fsetB, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := print(a, fsetA); got != want {
+		t.Errorf("files changed by the comparison:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestComparePackageFilesErrors(t *testing.T) {
+	files, fset := parseTestFiles(t, map[string]string{"a.go": "package p\n", "b.go": "package q\n"})
+	if _, err := ComparePackageFiles(files, fset, files[:1], fset, nil); err == nil {
+		t.Error("expected an error for files of different packages")
+	}
+	if _, err := ComparePackageFiles(nil, fset, files[:1], fset, nil); err == nil {
+		t.Error("expected an error for no files")
+	}
+}
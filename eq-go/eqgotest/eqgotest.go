@@ -0,0 +1,137 @@
+// Package eqgotest provides helpers for tests which check generated Go code against golden files
+// and directories with eq-go, e.g. in unit tests of code generators.
+//
+// Run the tests with -eqgotest.update to rewrite the golden files from the generated code instead
+// of comparing them. The flag is prefixed with the package name so that it doesn't collide with an
+// -update flag defined by the tests themselves.
+package eqgotest
+
+import (
+	"flag"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	eqgo "github.com/kevinmbeaulieu/eq-go/eq-go"
+)
+
+var update = flag.Bool("eqgotest.update", false, "Rewrite golden files and directories instead of comparing against them")
+
+// AssertPackagesEquivalent reports an error through t, with the differences found, unless the
+// packages in the directory tree gotDir are equivalent to those in the golden directory tree
+// wantDir, as for eqgo.CompareDirs. At most one Options may be given.
+//
+// With -eqgotest.update, the Go files of wantDir are replaced by those of gotDir instead, and no
+// comparison is made.
+func AssertPackagesEquivalent(t testing.TB, gotDir string, wantDir string, opts ...*eqgo.Options) {
+	t.Helper()
+
+	if *update {
+		if err := replaceGoFiles(wantDir, gotDir); err != nil {
+			t.Fatalf("updating %s: %v", wantDir, err)
+		}
+		return
+	}
+
+	r, err := eqgo.CompareDirs(gotDir, wantDir, options(t, opts))
+	if err != nil {
+		t.Fatalf("comparing %s with %s: %v", gotDir, wantDir, err)
+	}
+	if !r.Equivalent {
+		t.Errorf("%s is not equivalent to %s (run with -eqgotest.update to rewrite it):\n%s", gotDir, wantDir, r.Format(nil))
+	}
+}
+
+// AssertSourceEquivalent reports an error through t, with the differences found, unless the Go
+// source got is equivalent to the golden file wantFile, as for eqgo.CompareFiles. Source which
+// fails to parse is reported as an error too. At most one Options may be given.
+//
+// With -eqgotest.update, wantFile is overwritten with got instead, and no comparison is made.
+func AssertSourceEquivalent(t testing.TB, got []byte, wantFile string, opts ...*eqgo.Options) {
+	t.Helper()
+
+	if *update {
+		if err := ioutil.WriteFile(wantFile, got, 0644); err != nil {
+			t.Fatalf("updating %s: %v", wantFile, err)
+		}
+		return
+	}
+
+	fset := token.NewFileSet()
+	gotAST, err := parser.ParseFile(fset, "got.go", got, parser.AllErrors)
+	if err != nil {
+		t.Fatalf("parsing generated source: %v", err)
+	}
+	wantAST, err := parser.ParseFile(fset, wantFile, nil, parser.AllErrors)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", wantFile, err)
+	}
+
+	r, err := eqgo.CompareFiles(gotAST, fset, wantAST, fset, options(t, opts))
+	if err != nil {
+		t.Fatalf("comparing with %s: %v", wantFile, err)
+	}
+	if !r.Equivalent {
+		t.Errorf("generated source is not equivalent to %s (run with -eqgotest.update to rewrite it):\n%s", wantFile, r.Format(nil))
+	}
+}
+
+func options(t testing.TB, opts []*eqgo.Options) *eqgo.Options {
+	t.Helper()
+
+	switch len(opts) {
+	case 0:
+		return nil
+	case 1:
+		return opts[0]
+	}
+	t.Fatalf("at most one Options may be given, got %d", len(opts))
+	return nil
+}
+
+// Replace the Go files below dst with those below src, leaving any other files in place.
+func replaceGoFiles(dst string, src string) error {
+	err := filepath.Walk(dst, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && path == dst {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".go") {
+			return os.Remove(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, b, 0644)
+	})
+}
@@ -0,0 +1,131 @@
+package eqgotest
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	eqgo "github.com/kevinmbeaulieu/eq-go/eq-go"
+)
+
+// Tests which use this package may define their own -update flag, which would panic on a collision
+// with this package's flag.
+var _ = flag.Bool("update", false, "Update the test's own golden files")
+
+// Records the failures reported to it instead of failing the test.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// Run f with a recorder, and return the failures it reported.
+func record(t *testing.T, f func(tb testing.TB)) []string {
+	r := &recorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f(r)
+	}()
+	<-done
+	return r.failures
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "eqgotest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestAssertPackagesEquivalent(t *testing.T) {
+	got := writeFiles(t, map[string]string{"p/p.go": "package p\nconst N = 0x10\nfunc F() {}\n"})
+	defer os.RemoveAll(got)
+	want := writeFiles(t, map[string]string{"p/a.go": "package p\nfunc F() {\n}\n", "p/b.go": "package p\nconst N = 16\n"})
+	defer os.RemoveAll(want)
+
+	if failures := record(t, func(tb testing.TB) { AssertPackagesEquivalent(tb, got, want, &eqgo.Options{FoldConstants: true}) }); len(failures) > 0 {
+		t.Errorf("equivalent packages reported failures: %v", failures)
+	}
+	if failures := record(t, func(tb testing.TB) { AssertPackagesEquivalent(tb, got, want) }); len(failures) != 1 {
+		t.Errorf("non-equivalent packages reported %d failures, want 1: %v", len(failures), failures)
+	}
+	if failures := record(t, func(tb testing.TB) { AssertPackagesEquivalent(tb, got, filepath.Join(want, "missing")) }); len(failures) != 1 {
+		t.Errorf("missing golden directory reported %d failures, want 1: %v", len(failures), failures)
+	}
+}
+
+func TestAssertSourceEquivalent(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"want.go": "package p\n\n// F does nothing.\nfunc F() {}\n"})
+	defer os.RemoveAll(dir)
+	want := filepath.Join(dir, "want.go")
+
+	testCases := []struct {
+		got          string
+		wantFailures int
+	}{
+		{got: "package p; func F() {\n}", wantFailures: 0},
+		{got: "package p; func F() { println() }", wantFailures: 1},
+		{got: "package p; func F() {", wantFailures: 1},
+	}
+	for _, tc := range testCases {
+		failures := record(t, func(tb testing.TB) { AssertSourceEquivalent(tb, []byte(tc.got), want) })
+		if len(failures) != tc.wantFailures {
+			t.Errorf("AssertSourceEquivalent(%q) reported %d failures, want %d: %v", tc.got, len(failures), tc.wantFailures, failures)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	*update = true
+	defer func() { *update = false }()
+
+	got := writeFiles(t, map[string]string{"p/p.go": "package p\nfunc F() {}\n"})
+	defer os.RemoveAll(got)
+	want := writeFiles(t, map[string]string{"p/old.go": "package p\n", "README": "golden files"})
+	defer os.RemoveAll(want)
+
+	if failures := record(t, func(tb testing.TB) { AssertPackagesEquivalent(tb, got, want) }); len(failures) > 0 {
+		t.Fatalf("updating reported failures: %v", failures)
+	}
+	if _, err := os.Stat(filepath.Join(want, "p", "old.go")); !os.IsNotExist(err) {
+		t.Error("stale golden file was not removed")
+	}
+	if _, err := os.Stat(filepath.Join(want, "README")); err != nil {
+		t.Errorf("other golden files were not kept: %v", err)
+	}
+
+	wantFile := filepath.Join(want, "p", "p.go")
+	if failures := record(t, func(tb testing.TB) { AssertSourceEquivalent(tb, []byte("package p\n"), wantFile) }); len(failures) > 0 {
+		t.Fatalf("updating reported failures: %v", failures)
+	}
+	if b, err := ioutil.ReadFile(wantFile); err != nil || string(b) != "package p\n" {
+		t.Errorf("golden file = %q, %v, want %q", b, err, "package p\n")
+	}
+}
@@ -0,0 +1,64 @@
+package eqgo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+)
+
+// FingerprintVersion identifies the canonical form and digest algorithm used by Fingerprint. It is
+// part of every digest, and changes whenever digests of the same input would change, so digests
+// of different versions never compare equal by accident.
+const FingerprintVersion = 1
+
+// Fingerprints holds digests of the canonical form of a file or package.
+type Fingerprints struct {
+	// Digest of the whole file or package.
+	Digest string
+
+	// Digest of each top-level declaration, keyed as in DeclarationResult.
+	Declarations map[string]string
+}
+
+// Fingerprint returns digests of the canonical form of f, as produced by Canonicalize, for the
+// file as a whole and for each of its top-level declarations. Files which canonicalize to the same
+// form have the same digests, so digests can be stored and compared in place of the source. The
+// package name is not part of the digests.
+//
+// Digests have the form "eqgo<version>:sha256:<hex>".
+func Fingerprint(f *ast.File) (*Fingerprints, error) {
+	defer beginComparison("", "")()
+	c := canonicalize(f)
+
+	fp := &Fingerprints{Declarations: make(map[string]string)}
+	whole := sha256.New()
+	for _, d := range collectDeclarations(c) {
+		src, err := printCanonicalDecl(d.decl)
+		if err != nil {
+			return nil, err
+		}
+
+		digest := fingerprintDigest(src)
+		fp.Declarations[d.key] = digest
+		fmt.Fprintf(whole, "%s\x00%s\n", d.key, digest)
+	}
+	fp.Digest = fmt.Sprintf("eqgo%d:sha256:%s", FingerprintVersion, hex.EncodeToString(whole.Sum(nil)))
+
+	return fp, nil
+}
+
+// FingerprintPackage returns digests of the canonical form of the package made up of files, as for
+// Fingerprint, with the files merged into one. The files must all belong to the same package.
+func FingerprintPackage(files []*ast.File) (*Fingerprints, error) {
+	name, err := filesPackageName(files, nil)
+	if err != nil {
+		return nil, err
+	}
+	return Fingerprint(mergeFiles(name, files))
+}
+
+func fingerprintDigest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return fmt.Sprintf("eqgo%d:sha256:%s", FingerprintVersion, hex.EncodeToString(sum[:]))
+}
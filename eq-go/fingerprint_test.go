@@ -0,0 +1,86 @@
+package eqgo
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	testCases := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{
+			a:    "package p; import \"fmt\"; func F() { fmt.Println(0x10) }; type T int",
+			b:    "package q\n\nimport \"fmt\"\n\ntype T int\n\n// F prints.\nfunc F() {\n\tfmt.Println(16)\n}\n",
+			want: true,
+		},
+		{
+			a:    "package p; func F() int { return 1 }",
+			b:    "package p; func F() int { return 2 }",
+			want: false,
+		},
+	}
+	for _, c := range testCases {
+		fset := token.NewFileSet()
+		fileA, err := parser.ParseFile(fset, "a.go", c.a, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileB, err := parser.ParseFile(fset, "b.go", c.b, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fpA, err := Fingerprint(fileA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fpB, err := Fingerprint(fileB)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := fpA.Digest == fpB.Digest; got != c.want {
+			t.Errorf("Fingerprint(%q) == Fingerprint(%q) is %t, want %t", c.a, c.b, got, c.want)
+		}
+		if !strings.HasPrefix(fpA.Digest, "eqgo1:sha256:") {
+			t.Errorf("Fingerprint(%q).Digest == %q, want a versioned sha256 digest", c.a, fpA.Digest)
+		}
+	}
+}
+
+func TestFingerprintDeclarations(t *testing.T) {
+	fset := token.NewFileSet()
+	fileA, err := parser.ParseFile(fset, "a.go", "package p; func F() {}; func G() { F() }", parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileB, err := parser.ParseFile(fset, "b.go", "package p; func G() { F(); F() }; func F() {}", parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fpA, err := FingerprintPackage([]*ast.File{fileA})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fpB, err := Fingerprint(fileB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fpA.Declarations["func F"] != fpB.Declarations["func F"] {
+		t.Errorf("digests of func F differ: %s != %s", fpA.Declarations["func F"], fpB.Declarations["func F"])
+	}
+	if fpA.Declarations["func G"] == fpB.Declarations["func G"] {
+		t.Errorf("digests of func G are equal, want them to differ")
+	}
+	if fpA.Digest == fpB.Digest {
+		t.Errorf("package digests are equal, want them to differ")
+	}
+}
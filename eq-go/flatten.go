@@ -0,0 +1,179 @@
+package eqgo
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+)
+
+// Helpers to flatten grouped and multi-name declarations so that, e.g., `var (a int; b int)`,
+// `var a int; var b int` and `var a, b int` all produce the same syntax tree:
+//
+//   - Each spec of a grouped var, const or type declaration becomes its own declaration.
+//   - Each name of a multi-name var or const spec becomes its own spec, unless the names are
+//     assigned from a single multi-valued expression, as in `var a, b = f()`.
+//   - Each name of a multi-name struct field, parameter or result becomes its own field.
+//
+// Const specs which rely on implicit repetition of the previous spec's type and values have those
+// spelled out, with iota replaced by its value, since they lose their meaning outside the group.
+
+func flattenDeclarations(f *ast.File) {
+	var decls []ast.Decl
+	for _, d := range f.Decls {
+		if genDecl, ok := d.(*ast.GenDecl); ok && genDecl.Tok != token.IMPORT {
+			for _, g := range flattenGenDecl(genDecl) {
+				decls = append(decls, g)
+			}
+			continue
+		}
+		decls = append(decls, d)
+	}
+	f.Decls = decls
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FieldList:
+			flattenFieldList(x)
+		case *ast.BlockStmt:
+			x.List = flattenDeclStatements(x.List)
+		case *ast.CaseClause:
+			x.Body = flattenDeclStatements(x.Body)
+		case *ast.CommClause:
+			x.Body = flattenDeclStatements(x.Body)
+		}
+		return true
+	})
+}
+
+// Split a declaration into one declaration per (single-name) spec.
+func flattenGenDecl(d *ast.GenDecl) []*ast.GenDecl {
+	if d.Tok == token.CONST {
+		materializeConstSpecs(d)
+	}
+
+	var decls []*ast.GenDecl
+	for _, s := range d.Specs {
+		for _, split := range splitSpec(s) {
+			decls = append(decls, &ast.GenDecl{
+				Doc:    d.Doc,
+				TokPos: d.TokPos,
+				Tok:    d.Tok,
+				Specs:  []ast.Spec{split},
+			})
+		}
+	}
+	return decls
+}
+
+func splitSpec(s ast.Spec) []ast.Spec {
+	valueSpec, ok := s.(*ast.ValueSpec)
+	if !ok || len(valueSpec.Names) < 2 {
+		return []ast.Spec{s}
+	}
+	if len(valueSpec.Values) != 0 && len(valueSpec.Values) != len(valueSpec.Names) {
+		return []ast.Spec{s}
+	}
+
+	var specs []ast.Spec
+	for i, name := range valueSpec.Names {
+		split := &ast.ValueSpec{
+			Doc:     valueSpec.Doc,
+			Names:   []*ast.Ident{name},
+			Type:    valueSpec.Type,
+			Comment: valueSpec.Comment,
+		}
+		if len(valueSpec.Values) > 0 {
+			split.Values = []ast.Expr{valueSpec.Values[i]}
+		}
+		specs = append(specs, split)
+	}
+	return specs
+}
+
+// Spell out the implicitly repeated type and values of each spec in a const declaration, and replace
+// iota with its value in every spec.
+func materializeConstSpecs(d *ast.GenDecl) {
+	var prevType ast.Expr
+	var prevValues []ast.Expr
+
+	for i, s := range d.Specs {
+		spec, ok := s.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+
+		if len(spec.Values) == 0 {
+			spec.Type = prevType
+			for _, v := range prevValues {
+				spec.Values = append(spec.Values, cloneNode(v).(ast.Expr))
+			}
+		} else {
+			prevType = spec.Type
+			prevValues = nil
+			for _, v := range spec.Values {
+				prevValues = append(prevValues, cloneNode(v).(ast.Expr))
+			}
+		}
+
+		iota := strconv.Itoa(i)
+		for j := range spec.Values {
+			spec.Values[j] = replaceIota(spec.Values[j], iota)
+		}
+	}
+}
+
+func replaceIota(x ast.Expr, value string) ast.Expr {
+	replace := func(x ast.Expr, _ ast.Node) ast.Expr {
+		if ident, ok := x.(*ast.Ident); ok && ident.Name == "iota" {
+			return &ast.BasicLit{ValuePos: ident.Pos(), Kind: token.INT, Value: value}
+		}
+		return x
+	}
+
+	// Wrap x so that x itself can be replaced too.
+	holder := &ast.ParenExpr{X: x}
+	rewriteExpressions(holder, replace)
+	return holder.X
+}
+
+// Split fields which declare several names into one field per name.
+func flattenFieldList(l *ast.FieldList) {
+	var fields []*ast.Field
+	for _, field := range l.List {
+		if len(field.Names) < 2 {
+			fields = append(fields, field)
+			continue
+		}
+		for _, name := range field.Names {
+			fields = append(fields, &ast.Field{
+				Doc:     field.Doc,
+				Names:   []*ast.Ident{name},
+				Type:    field.Type,
+				Tag:     field.Tag,
+				Comment: field.Comment,
+			})
+		}
+	}
+	l.List = fields
+}
+
+// Split declaration statements which declare several things into one statement per declaration.
+func flattenDeclStatements(list []ast.Stmt) []ast.Stmt {
+	var stmts []ast.Stmt
+	for _, s := range list {
+		declStmt, ok := s.(*ast.DeclStmt)
+		if !ok {
+			stmts = append(stmts, s)
+			continue
+		}
+		genDecl, ok := declStmt.Decl.(*ast.GenDecl)
+		if !ok {
+			stmts = append(stmts, s)
+			continue
+		}
+		for _, g := range flattenGenDecl(genDecl) {
+			stmts = append(stmts, &ast.DeclStmt{Decl: g})
+		}
+	}
+	return stmts
+}
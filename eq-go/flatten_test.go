@@ -0,0 +1,68 @@
+package eqgo
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestFlattenDeclarations(t *testing.T) {
+	testCases := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{a: "var (a int; b int)", b: "var a int; var b int", want: true},
+		{a: "var a, b int", b: "var (b int; a int)", want: true},
+		{a: "var a, b = 1, 2", b: "var b = 2; var a = 1", want: true},
+		{a: "var a, b = f()", b: "var a = f(); var b = f()", want: false},
+		{a: "const (A = iota; B; C)", b: "const C = 2; const B = 1; const A = 0", want: true},
+		{a: "const (A = iota * 2; B)", b: "const A = 0 * 2; const B = 1 * 2", want: true},
+		{a: "const (A int = 1; B)", b: "const A int = 1; const B int = 1", want: true},
+		{a: "const (A = iota; B)", b: "const (B = iota; A)", want: false},
+		{a: "type (A int; B string)", b: "type B string; type A int", want: true},
+		{a: "type T struct { X, Y int }", b: "type T struct { X int; Y int }", want: true},
+		{a: "type T struct { X, Y int }", b: "type T struct { Y int; X int }", want: false},
+		{a: "func f(a, b int) (c, d string) {}", b: "func f(a int, b int) (c string, d string) {}", want: true},
+		{a: "func f() { var (a int; b = 2); _, _ = a, b }", b: "func f() { var a int; var b = 2; _, _ = a, b }", want: true},
+	}
+	for _, c := range testCases {
+		fset := token.NewFileSet()
+		fileA, err := parser.ParseFile(fset, "a.go", "package p; "+c.a, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileB, err := parser.ParseFile(fset, "b.go", "package p; "+c.b, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := CompareFiles(fileA, fset, fileB, fset, &Options{FlattenDeclarations: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Equivalent != c.want {
+			t.Errorf("CompareFiles(%q, %q) == %t, want %t\n%s", c.a, c.b, got.Equivalent, c.want, got.Format(nil))
+		}
+	}
+}
+
+func TestFlattenDeclarationsWithFoldConstants(t *testing.T) {
+	fset := token.NewFileSet()
+	fileA, err := parser.ParseFile(fset, "a.go", "package p; const (A = 1 << iota; B; C)", parser.AllErrors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileB, err := parser.ParseFile(fset, "b.go", "package p; const A = 1; const B = 2; const C = 4", parser.AllErrors)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := CompareFiles(fileA, fset, fileB, fset, &Options{FlattenDeclarations: true, FoldConstants: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equivalent {
+		t.Errorf("CompareFiles() == false, want true\n%s", got.Format(nil))
+	}
+}
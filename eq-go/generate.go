@@ -0,0 +1,280 @@
+package eqgo
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GeneratorOutputEnv is the environment variable which names the directory a Generator must write
+// its output to, unless it regenerates its output in place.
+const GeneratorOutputEnv = "EQGO_OUT"
+
+// Generator describes a command which generates Go packages, for VerifyGenerated.
+type Generator struct {
+	// Command to run and its arguments, e.g. []string{"go", "generate", "./..."}.
+	Command []string
+
+	// Whether the command regenerates its output in place, as `go generate` does. If set, the
+	// command is run in the corresponding directory of a temporary copy of the module containing
+	// the committed directory (or of the committed directory alone, if it isn't in a module), so
+	// that go commands work as they would in the original. Committed Go files marked as generated,
+	// by a "// Code generated ... DO NOT EDIT." comment, are removed from the copy first, so that
+	// outputs the command no longer generates aren't mistaken for its output. Otherwise the command
+	// is run in the current directory, and must write its output to the empty temporary directory
+	// named by the GeneratorOutputEnv environment variable.
+	InPlace bool
+}
+
+// GeneratedResult describes the outcome of VerifyGenerated.
+type GeneratedResult struct {
+	// Comparison of the committed packages, on the left, with the regenerated packages, on the
+	// right.
+	Result *DirsResult
+
+	// Combined standard output and standard error of the generator.
+	Output []byte
+
+	// Files of the committed directory which were overwritten with, or removed to match, the
+	// regenerated output, as slash-separated paths relative to the committed directory, sorted.
+	Updated []string
+}
+
+// VerifyGenerated runs gen to regenerate the Go packages committed in dir, and compares the
+// committed packages with the regenerated ones as for CompareDirs with opts. The regenerated output
+// is written to a temporary directory, which is removed afterwards.
+//
+// If update is set and the packages are equivalent, the Go files of dir which were compared are
+// replaced by the regenerated ones, so that differences which don't affect equivalence, such as
+// formatting, comments or the order of declarations, are brought up to date. Otherwise dir is left
+// unchanged. Updating is not supported in subset mode, since it would remove the committed
+// declarations the generator doesn't produce.
+//
+// A generator which fails causes an error to be returned, which includes its output.
+//
+// opts may be nil.
+func VerifyGenerated(dir string, gen Generator, update bool, opts *Options) (*GeneratedResult, error) {
+	if len(gen.Command) == 0 {
+		return nil, fmt.Errorf("missing generator command")
+	}
+	if update && opts != nil && opts.Subset {
+		return nil, fmt.Errorf("updating generated files is not supported in subset mode")
+	}
+
+	tmp, err := ioutil.TempDir("", "eqgo-generate")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	out := tmp
+	cmd := exec.Command(gen.Command[0], gen.Command[1:]...)
+	cmd.Env = append(os.Environ(), GeneratorOutputEnv+"="+tmp)
+	if gen.InPlace {
+		if out, err = copyForGenerating(dir, tmp, opts); err != nil {
+			return nil, err
+		}
+		cmd.Dir = out
+	}
+
+	r := &GeneratedResult{}
+	if r.Output, err = cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("running %s: %v\n%s", strings.Join(gen.Command, " "), err, r.Output)
+	}
+
+	if r.Result, err = CompareDirs(dir, out, opts); err != nil {
+		return nil, err
+	}
+
+	if update && r.Result.Equivalent {
+		if r.Updated, err = syncGoFiles(dir, out, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Copy the module containing dir, or dir alone if it isn't in a module, to tmp, and remove the
+// committed generated files compared below dir from the copy. Returns the copy of dir.
+func copyForGenerating(dir string, tmp string, opts *Options) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	root := moduleRoot(dir)
+	if root == "" {
+		root = dir
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return "", err
+	}
+
+	if err := copyTree(root, tmp); err != nil {
+		return "", err
+	}
+	out := filepath.Join(tmp, rel)
+
+	files, err := comparedFiles(dir, opts)
+	if err != nil {
+		return "", err
+	}
+	for f := range files {
+		generated, err := isGeneratedFile(filepath.Join(dir, filepath.FromSlash(f)))
+		if err != nil {
+			return "", err
+		}
+		if !generated {
+			continue
+		}
+		if err := os.Remove(filepath.Join(out, filepath.FromSlash(f))); err != nil {
+			return "", err
+		}
+	}
+	return out, nil
+}
+
+// The closest directory containing dir, or dir itself, which has a go.mod file, or "" if there is
+// none.
+func moduleRoot(dir string) string {
+	for {
+		if info, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil && !info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+var generatedComment = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// Whether a Go file is marked as generated, following the convention described at
+// https://golang.org/s/generatedcode: a line matching generatedComment before the first
+// non-comment, non-blank text in the file.
+func isGeneratedFile(filename string) (bool, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return false, err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if generatedComment.MatchString(line) {
+			return true, nil
+		}
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "//") {
+			return false, nil
+		}
+	}
+	return false, nil
+}
+
+// Copy the files below src to dst, except for version control metadata.
+func copyTree(src string, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, b, info.Mode().Perm())
+	})
+}
+
+// Overwrite the Go files compared below dst with those compared below src, and remove those which
+// don't exist below src. Returns the paths of the files changed, relative to dst.
+func syncGoFiles(dst string, src string, opts *Options) ([]string, error) {
+	srcFiles, err := comparedFiles(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	dstFiles, err := comparedFiles(dst, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []string
+	for rel := range srcFiles {
+		b, err := ioutil.ReadFile(filepath.Join(src, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+
+		target := filepath.Join(dst, filepath.FromSlash(rel))
+		if current, err := ioutil.ReadFile(target); err == nil && bytes.Equal(current, b) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(target, b, 0644); err != nil {
+			return nil, err
+		}
+		updated = append(updated, rel)
+	}
+	for rel := range dstFiles {
+		if srcFiles[rel] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dst, filepath.FromSlash(rel))); err != nil {
+			return nil, err
+		}
+		updated = append(updated, rel)
+	}
+
+	sort.Strings(updated)
+	return updated, nil
+}
+
+// Slash-separated paths, relative to root, of the files CompareDirs compares below root with opts.
+func comparedFiles(root string, opts *Options) (map[string]bool, error) {
+	pkgs, err := loadPackageDirs(root, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]bool)
+	for _, p := range pkgs {
+		loaded := p.Files
+		if opts != nil && opts.IncludeTests {
+			loaded = append(append(append([]*ast.File{}, loaded...), p.TestFiles...), p.ExternalTestFiles...)
+		}
+		for _, f := range loaded {
+			rel, err := filepath.Rel(root, p.Fset.Position(f.Pos()).Filename)
+			if err != nil {
+				return nil, err
+			}
+			files[filepath.ToSlash(rel)] = true
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,183 @@
+package eqgo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVerifyGenerated(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	testCases := []struct {
+		name        string
+		committed   map[string]string
+		gen         Generator
+		update      bool
+		want        bool
+		wantUpdated []string
+		wantFiles   map[string]string
+	}{
+		{
+			name:      "equivalent output",
+			committed: map[string]string{"p/p.go": "package p\nfunc F() {}\n"},
+			gen:       Generator{Command: []string{"sh", "-c", `mkdir "$EQGO_OUT/p" && printf 'package p\n\n// F does nothing.\nfunc F() {\n}\n' > "$EQGO_OUT/p/p.go"`}},
+			want:      true,
+			wantFiles: map[string]string{"p/p.go": "package p\nfunc F() {}\n"},
+		},
+		{
+			name:        "equivalent output updated",
+			committed:   map[string]string{"p/a.go": "package p\nfunc F() {}\n", "p/b.go": "package p\nfunc G() {}\n", "p/notes.txt": "notes"},
+			gen:         Generator{Command: []string{"sh", "-c", `mkdir "$EQGO_OUT/p" && printf 'package p\n\nfunc G() {}\n\nfunc F() {}\n' > "$EQGO_OUT/p/all.go"`}},
+			update:      true,
+			want:        true,
+			wantUpdated: []string{"p/a.go", "p/all.go", "p/b.go"},
+			wantFiles:   map[string]string{"p/all.go": "package p\n\nfunc G() {}\n\nfunc F() {}\n", "p/notes.txt": "notes"},
+		},
+		{
+			name:      "non-equivalent output not updated",
+			committed: map[string]string{"p.go": "package p\nfunc F() {}\n"},
+			gen:       Generator{Command: []string{"sh", "-c", `printf 'package p\nfunc F() { println() }\n' > "$EQGO_OUT/p.go"`}},
+			update:    true,
+			want:      false,
+			wantFiles: map[string]string{"p.go": "package p\nfunc F() {}\n"},
+		},
+		{
+			name:        "generated in place",
+			committed:   map[string]string{"p.go": "package p\nfunc F() {}\n", "gen.sh": `printf 'package p\n\nfunc F() {}\n' > p.go`},
+			gen:         Generator{Command: []string{"sh", "gen.sh"}, InPlace: true},
+			update:      true,
+			want:        true,
+			wantUpdated: []string{"p.go"},
+			wantFiles:   map[string]string{"p.go": "package p\n\nfunc F() {}\n"},
+		},
+		{
+			name:      "stale output generated in place",
+			committed: map[string]string{"p.go": "package p\nfunc F() {}\n", "old.go": "// Code generated by gen.sh. DO NOT EDIT.\n\npackage p\nfunc G() {}\n", "gen.sh": `printf 'package p\n\nfunc F() {}\n' > p.go`},
+			gen:       Generator{Command: []string{"sh", "gen.sh"}, InPlace: true},
+			update:    true,
+			want:      false,
+			wantFiles: map[string]string{"p.go": "package p\nfunc F() {}\n", "old.go": "// Code generated by gen.sh. DO NOT EDIT.\n\npackage p\nfunc G() {}\n"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeTree(t, tc.committed)
+			defer os.RemoveAll(dir)
+
+			r, err := VerifyGenerated(dir, tc.gen, tc.update, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Result.Equivalent != tc.want {
+				t.Errorf("Equivalent = %t, want %t\n%s", r.Result.Equivalent, tc.want, r.Result.Format(nil))
+			}
+			if !reflect.DeepEqual(r.Updated, tc.wantUpdated) {
+				t.Errorf("Updated = %v, want %v", r.Updated, tc.wantUpdated)
+			}
+
+			for name, want := range tc.wantFiles {
+				got, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			for _, name := range tc.wantUpdated {
+				if _, ok := tc.wantFiles[name]; ok {
+					continue
+				}
+				if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); !os.IsNotExist(err) {
+					t.Errorf("%s was not removed", name)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyGeneratedWithGoGenerate(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not available")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	generated := "// Code generated by gen.sh. DO NOT EDIT.\n\npackage p\n\nfunc F() int { return 1 }\n"
+	testCases := []struct {
+		name      string
+		committed map[string]string
+		want      bool
+	}{
+		{
+			name:      "up to date",
+			committed: map[string]string{"p/f.go": "// Code generated by gen.sh. DO NOT EDIT.\npackage p\nfunc F() int {\n\treturn 1\n}\n"},
+			want:      true,
+		},
+		{
+			name:      "out of date",
+			committed: map[string]string{"p/f.go": "// Code generated by gen.sh. DO NOT EDIT.\npackage p\nfunc F() int { return 2 }\n"},
+			want:      false,
+		},
+		{
+			name: "stale output",
+			committed: map[string]string{
+				"p/f.go":   generated,
+				"p/old.go": "// Code generated by gen.sh. DO NOT EDIT.\npackage p\nfunc G() {}\n",
+			},
+			want: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := map[string]string{
+				"go.mod":   "module example.com/m\n\ngo 1.14\n",
+				"p/doc.go": "// Package p is generated.\npackage p\n\n//go:generate sh gen.sh\n",
+				"p/gen.sh": fmt.Sprintf("printf '%s' > f.go\n", strings.Replace(generated, "\n", `\n`, -1)),
+			}
+			for name, src := range tc.committed {
+				files[name] = src
+			}
+			root := writeTree(t, files)
+			defer os.RemoveAll(root)
+
+			r, err := VerifyGenerated(filepath.Join(root, "p"), Generator{Command: []string{"go", "generate", "./..."}, InPlace: true}, false, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Result.Equivalent != tc.want {
+				t.Errorf("Equivalent = %t, want %t\n%s", r.Result.Equivalent, tc.want, r.Result.Format(nil))
+			}
+		})
+	}
+}
+
+func TestVerifyGeneratedErrors(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	dir := writeTree(t, map[string]string{"p.go": "package p\n"})
+	defer os.RemoveAll(dir)
+
+	if _, err := VerifyGenerated(dir, Generator{Command: []string{"sh", "-c", "exit 3"}}, false, nil); err == nil {
+		t.Error("VerifyGenerated() with a failing generator returned no error")
+	}
+	if _, err := VerifyGenerated(dir, Generator{}, false, nil); err == nil {
+		t.Error("VerifyGenerated() without a command returned no error")
+	}
+	if _, err := VerifyGenerated(dir, Generator{Command: []string{"sh", "-c", "true"}}, true, &Options{Subset: true}); err == nil {
+		t.Error("VerifyGenerated() updating in subset mode returned no error")
+	}
+}
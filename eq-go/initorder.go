@@ -0,0 +1,193 @@
+package eqgo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// Helpers to compare the order in which package-level variables are initialized and init functions
+// run, which the comparison of declarations otherwise ignores.
+
+// A package-level variable initializer or init function, in the order it runs.
+type initStep struct {
+	// Identifies the step across both sides. Steps with the same key on each side are equivalent.
+	key string
+
+	// Describes the step in messages, e.g. "var x" or "func init".
+	desc string
+
+	node ast.Node
+}
+
+// Builtin functions whose calls have side effects.
+var sideEffectBuiltins = map[string]bool{
+	"clear":   true,
+	"close":   true,
+	"copy":    true,
+	"delete":  true,
+	"panic":   true,
+	"print":   true,
+	"println": true,
+	"recover": true,
+}
+
+// Compare the order in which the side-effecting package-level variable initializers and the init
+// functions of two packages run, following the initialization order defined by the spec. Steps
+// found on only one side are ignored, since the comparison of declarations already reports them.
+//
+// The packages are type-checked to find their initialization order, so must be compared before
+// their files are normalized.
+func compareInitOrder(nameA string, filesA []*ast.File, fsetA *token.FileSet, nameB string, filesB []*ast.File, fsetB *token.FileSet) (int, *node, error) {
+	imp := newImporter()
+	varsA, initsA, err := initSteps(nameA, filesA, fsetA, imp)
+	if err != nil {
+		return 0, nil, err
+	}
+	varsB, initsB, err := initSteps(nameB, filesB, fsetB, imp)
+	if err != nil {
+		return 0, nil, err
+	}
+	matchInitFuncs(initsA, initsB)
+
+	retCmp := 0
+	var children []*node
+
+	if cmp, child := compareInitSteps(varsA, varsB); cmp != 0 {
+		setIfUnset(&retCmp, cmp)
+		children = append(children, newNode("order of side-effecting variable initializers did not match", nil, nil, &[]*node{child}))
+	}
+	if cmp, child := compareInitSteps(initsA, initsB); cmp != 0 {
+		setIfUnset(&retCmp, cmp)
+		children = append(children, newNode("order of init functions did not match", nil, nil, &[]*node{child}))
+	}
+
+	cmp, n := newRetVal(retCmp, "initialization order did not match", nil, nil, children)
+	return cmp, n, nil
+}
+
+// The side-effecting package-level variable initializers of a package, in initialization order, and
+// its init functions, in the order they run.
+func initSteps(name string, files []*ast.File, fset *token.FileSet, imp types.Importer) ([]initStep, []initStep, error) {
+	checked, err := typeCheck(name, files, fset, imp)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var vars []initStep
+	for _, initializer := range checked.info.InitOrder {
+		if !hasSideEffects(initializer.Rhs, checked.info) {
+			continue
+		}
+
+		var names []string
+		for _, v := range initializer.Lhs {
+			names = append(names, v.Name())
+		}
+		key := "var " + strings.Join(names, ", ")
+		vars = append(vars, initStep{key: key, desc: key, node: initializer.Rhs})
+	}
+
+	// Init functions run in the order they appear in the files, which are presented in order.
+	var inits []initStep
+	for _, f := range files {
+		for _, d := range f.Decls {
+			if funcDecl, ok := d.(*ast.FuncDecl); ok && funcDecl.Recv == nil && funcDecl.Name.Name == "init" {
+				inits = append(inits, initStep{desc: "func init", node: funcDecl})
+			}
+		}
+	}
+
+	return vars, inits, nil
+}
+
+// Whether evaluating e may have side effects, i.e. whether it calls a function or receives from a
+// channel. Function literals are not called by being evaluated, and conversions and most builtins
+// have no side effects.
+func hasSideEffects(e ast.Expr, info *types.Info) bool {
+	found := false
+	ast.Inspect(e, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.UnaryExpr:
+			if x.Op == token.ARROW {
+				found = true
+			}
+		case *ast.CallExpr:
+			tv := info.Types[x.Fun]
+			switch {
+			case tv.IsType():
+			case tv.IsBuiltin():
+				fun := x.Fun
+				for paren, ok := fun.(*ast.ParenExpr); ok; paren, ok = fun.(*ast.ParenExpr) {
+					fun = paren.X
+				}
+				if ident, ok := fun.(*ast.Ident); ok && sideEffectBuiltins[ident.Name] {
+					found = true
+				}
+			default:
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// Give each init function a key shared with an equivalent init function on the other side, if any.
+// Since init functions have no names, they are paired up by comparing their bodies.
+func matchInitFuncs(a []initStep, b []initStep) {
+	matched := make([]bool, len(b))
+	for i := range a {
+		a[i].key = fmt.Sprintf("left %d", i)
+		for j := range b {
+			if matched[j] {
+				continue
+			}
+			// The comparison sorts parts of the trees it compares, so compare copies.
+			if cmp, _ := compareDecls(cloneNode(a[i].node).(ast.Decl), cloneNode(b[j].node).(ast.Decl)); cmp == 0 {
+				matched[j] = true
+				b[j].key = a[i].key
+				break
+			}
+		}
+	}
+	for j := range b {
+		if !matched[j] {
+			b[j].key = fmt.Sprintf("right %d", j)
+		}
+	}
+}
+
+// Compare the relative order of the steps found on both sides.
+func compareInitSteps(a []initStep, b []initStep) (int, *node) {
+	a, b = commonInitSteps(a, b), commonInitSteps(b, a)
+
+	for i := range a {
+		if a[i].key != b[i].key {
+			cmp := strings.Compare(a[i].key, b[i].key)
+			msg := fmt.Sprintf("steps at index %d did not match: %s != %s", i, a[i].desc, b[i].desc)
+			return newRetVal(cmp, msg, a[i].node, b[i].node, nil)
+		}
+	}
+	return 0, nil
+}
+
+// The steps of a which have a step with the same key in b, in order.
+func commonInitSteps(a []initStep, b []initStep) []initStep {
+	keys := make(map[string]bool)
+	for _, s := range b {
+		keys[s.key] = true
+	}
+
+	var common []initStep
+	for _, s := range a {
+		if keys[s.key] {
+			common = append(common, s)
+		}
+	}
+	return common
+}
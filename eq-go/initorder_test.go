@@ -0,0 +1,87 @@
+package eqgo
+
+import (
+	"testing"
+)
+
+func TestCompareInitOrder(t *testing.T) {
+	const funcs = "package p\nfunc f(x ...int) int { return 1 }\nfunc g() int { return 2 }\n"
+
+	testCases := []struct {
+		name        string
+		left, right map[string]string
+		want        bool
+	}{
+		{
+			name:  "reordered pure initializers",
+			left:  map[string]string{"p.go": funcs + "var a = 1\nvar b = int64(2)\nvar c = len(\"c\")\n"},
+			right: map[string]string{"p.go": funcs + "var c = len(\"c\")\nvar b = int64(2)\nvar a = 1\n"},
+			want:  true,
+		},
+		{
+			name:  "reordered side-effecting initializers",
+			left:  map[string]string{"p.go": funcs + "var a = f()\nvar b = g()\n"},
+			right: map[string]string{"p.go": funcs + "var b = g()\nvar a = f()\n"},
+			want:  false,
+		},
+		{
+			name:  "order fixed by dependencies",
+			left:  map[string]string{"p.go": funcs + "var a = f(b)\nvar b = g()\n"},
+			right: map[string]string{"p.go": funcs + "var b = g()\nvar a = f(b)\n"},
+			want:  true,
+		},
+		{
+			name:  "function literals not called",
+			left:  map[string]string{"p.go": funcs + "var a = func() int { return f() }\nvar b = g()\n"},
+			right: map[string]string{"p.go": funcs + "var b = g()\nvar a = func() int { return f() }\n"},
+			want:  true,
+		},
+		{
+			name:  "side-effecting initializers moved between files",
+			left:  map[string]string{"a.go": funcs + "var a = f()\n", "b.go": "package p\nvar b = g()\n"},
+			right: map[string]string{"a.go": funcs + "var b = g()\n", "b.go": "package p\nvar a = f()\n"},
+			want:  false,
+		},
+		{
+			name:  "reordered init functions",
+			left:  map[string]string{"p.go": funcs + "func init() { f() }\nfunc init() { g() }\n"},
+			right: map[string]string{"p.go": funcs + "func init() { g() }\nfunc init() { f() }\n"},
+			want:  false,
+		},
+		{
+			name:  "init functions moved between files",
+			left:  map[string]string{"a.go": funcs + "func init() { f() }\n", "b.go": "package p\nfunc init() { g() }\n"},
+			right: map[string]string{"a.go": funcs + "func init() { f() }\nfunc init() { g() }\n", "b.go": "package p\n"},
+			want:  true,
+		},
+		{
+			name:  "init functions reordered across files",
+			left:  map[string]string{"a.go": funcs + "func init() { f() }\n", "b.go": "package p\nfunc init() { g() }\n"},
+			right: map[string]string{"a.go": funcs + "func init() { g() }\n", "b.go": "package p\nfunc init() { f() }\n"},
+			want:  false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, fsetA := parseTestFiles(t, tc.left)
+			b, fsetB := parseTestFiles(t, tc.right)
+
+			r, err := ComparePackageFiles(a, fsetA, b, fsetB, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !r.Equivalent {
+				t.Fatalf("packages not equivalent without InitOrder:\n%s", r.Format(nil))
+			}
+
+			r, err = ComparePackageFiles(a, fsetA, b, fsetB, &Options{InitOrder: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Equivalent != tc.want {
+				t.Errorf("Equivalent = %t, want %t\n%s", r.Equivalent, tc.want, r.Format(nil))
+			}
+		})
+	}
+}
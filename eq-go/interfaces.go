@@ -0,0 +1,119 @@
+package eqgo
+
+import (
+	"go/ast"
+	"sort"
+)
+
+// Helpers to compare interface types by their method sets rather than by the order in which their
+// methods and embedded interfaces are listed.
+
+func normalizeInterfaces(f *ast.File, expandEmbedded bool) {
+	var interfaces map[string]*ast.InterfaceType
+	if expandEmbedded {
+		interfaces = packageInterfaces(f)
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		if x, ok := n.(*ast.InterfaceType); ok {
+			if expandEmbedded {
+				expandEmbeddedInterfaces(x, interfaces, map[string]bool{})
+			}
+			sortInterfaceMethods(x)
+		}
+		return true
+	})
+}
+
+// Interface types declared at the top level of a file, keyed by name.
+func packageInterfaces(f *ast.File) map[string]*ast.InterfaceType {
+	interfaces := make(map[string]*ast.InterfaceType)
+	for _, d := range f.Decls {
+		genDecl, ok := d.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, s := range genDecl.Specs {
+			typeSpec, ok := s.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			if x, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+				interfaces[typeSpec.Name.Name] = x
+			}
+		}
+	}
+	return interfaces
+}
+
+// Replace each embedded interface which is declared in the same package with the methods of that
+// interface. Methods which end up listed more than once are only kept once.
+//
+// expanding holds the names of the interfaces currently being expanded, to guard against (invalid)
+// cyclic embeddings.
+func expandEmbeddedInterfaces(x *ast.InterfaceType, interfaces map[string]*ast.InterfaceType, expanding map[string]bool) {
+	if x.Methods == nil {
+		return
+	}
+
+	var fields []*ast.Field
+	seen := make(map[string]bool)
+	add := func(field *ast.Field) {
+		if len(field.Names) > 0 {
+			if seen[field.Names[0].Name] {
+				return
+			}
+			seen[field.Names[0].Name] = true
+		}
+		fields = append(fields, field)
+	}
+
+	for _, field := range x.Methods.List {
+		ident, ok := field.Type.(*ast.Ident)
+		if len(field.Names) > 0 || !ok {
+			add(field)
+			continue
+		}
+
+		embedded, ok := interfaces[ident.Name]
+		if !ok || expanding[ident.Name] {
+			add(field)
+			continue
+		}
+
+		embedded = cloneNode(embedded).(*ast.InterfaceType)
+		expanding[ident.Name] = true
+		expandEmbeddedInterfaces(embedded, interfaces, expanding)
+		delete(expanding, ident.Name)
+
+		if embedded.Methods != nil {
+			for _, f := range embedded.Methods.List {
+				add(f)
+			}
+		}
+	}
+
+	x.Methods.List = fields
+}
+
+// Sort the elements of an interface type: embedded types first, ordered by type, then methods,
+// ordered by name.
+func sortInterfaceMethods(x *ast.InterfaceType) {
+	if x.Methods == nil {
+		return
+	}
+
+	list := x.Methods.List
+	sort.SliceStable(list, func(i, j int) bool {
+		embeddedI, embeddedJ := len(list[i].Names) == 0, len(list[j].Names) == 0
+		if embeddedI != embeddedJ {
+			return embeddedI
+		}
+		if embeddedI {
+			cmp, _ := compareExpressions(list[i].Type, list[j].Type)
+			return cmp < 0
+		}
+		cmp, _ := compareIdentifiers(list[i].Names[0], list[j].Names[0])
+		return cmp < 0
+	})
+}
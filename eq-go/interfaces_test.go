@@ -0,0 +1,80 @@
+package eqgo
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestUnorderedInterfaceMethods(t *testing.T) {
+	testCases := []struct {
+		a      string
+		b      string
+		expand bool
+		want   bool
+	}{
+		{
+			a:    "type I interface { A(); B() int }",
+			b:    "type I interface { B() int; A() }",
+			want: true,
+		},
+		{
+			a:    "type I interface { A(); B() int }",
+			b:    "type I interface { B() string; A() }",
+			want: false,
+		},
+		{
+			a:    "type I interface { io.Reader; fmt.Stringer; A() }",
+			b:    "type I interface { A(); fmt.Stringer; io.Reader }",
+			want: true,
+		},
+		{
+			a:    "func f(x interface { A(); B() }) {}",
+			b:    "func f(x interface { B(); A() }) {}",
+			want: true,
+		},
+		{
+			a:    "type R interface { Read() }; type I interface { R; Close() }",
+			b:    "type R interface { Read() }; type I interface { Close(); Read() }",
+			want: false,
+		},
+		{
+			a:      "type R interface { Read() }; type I interface { R; Close() }",
+			b:      "type R interface { Read() }; type I interface { Close(); Read() }",
+			expand: true,
+			want:   true,
+		},
+		{
+			a:      "type R interface { Read() }; type C interface { Close(); R }; type I interface { C; R }",
+			b:      "type R interface { Read() }; type C interface { Read(); Close() }; type I interface { Read(); Close() }",
+			expand: true,
+			want:   true,
+		},
+		{
+			a:      "type I interface { io.Reader }",
+			b:      "type I interface { Read(p []byte) (int, error) }",
+			expand: true,
+			want:   false,
+		},
+	}
+	for _, c := range testCases {
+		fset := token.NewFileSet()
+		fileA, err := parser.ParseFile(fset, "a.go", "package p; "+c.a, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileB, err := parser.ParseFile(fset, "b.go", "package p; "+c.b, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opts := &Options{UnorderedInterfaceMethods: true, ExpandEmbeddedInterfaces: c.expand}
+		got, err := CompareFiles(fileA, fset, fileB, fset, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Equivalent != c.want {
+			t.Errorf("CompareFiles(%q, %q) == %t, want %t\n%s", c.a, c.b, got.Equivalent, c.want, got.Format(nil))
+		}
+	}
+}
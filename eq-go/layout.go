@@ -0,0 +1,128 @@
+package eqgo
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LayoutResult describes how the top-level declarations of two packages are laid out across their
+// files. Files are identified by their base names, so that packages in different directories can
+// be compared.
+type LayoutResult struct {
+	// Declarations found on both sides which are in differently named files, sorted by key.
+	// Imports are not included, since they belong to whichever files use them.
+	Moves []DeclarationMove
+
+	// Files on the left whose declarations are spread across several files on the right, sorted by
+	// left file.
+	Splits []FileMapping
+
+	// Files on the right whose declarations come from several files on the left, sorted by right
+	// file.
+	Merges []FileMapping
+}
+
+// DeclarationMove describes a top-level declaration which is in a different file on each side.
+type DeclarationMove struct {
+	// Identifies the declaration within its package, as in DeclarationResult.
+	Key string
+
+	// Base names of the files containing the declaration on each side.
+	LeftFile, RightFile string
+}
+
+// FileMapping relates files on the left to the files on the right their declarations are found in.
+type FileMapping struct {
+	// Base names of the files on each side, sorted.
+	LeftFiles, RightFiles []string
+}
+
+// Moved reports whether any declarations are in different files on each side.
+func (r *LayoutResult) Moved() bool {
+	return len(r.Moves) > 0
+}
+
+// Format returns a message listing the declarations which moved, and the files which were split or
+// merged.
+func (r *LayoutResult) Format() string {
+	if !r.Moved() {
+		return "no declarations moved between files"
+	}
+
+	var builder strings.Builder
+	fmt.Fprint(&builder, "declarations moved between files:")
+	for _, m := range r.Moves {
+		fmt.Fprintf(&builder, "\n    %s: %s -> %s", m.Key, m.LeftFile, m.RightFile)
+	}
+	for _, s := range r.Splits {
+		fmt.Fprintf(&builder, "\n%s split into %s", s.LeftFiles[0], strings.Join(s.RightFiles, ", "))
+	}
+	for _, m := range r.Merges {
+		fmt.Fprintf(&builder, "\n%s merged into %s", strings.Join(m.LeftFiles, ", "), m.RightFiles[0])
+	}
+	return builder.String()
+}
+
+// Work out how the declarations found on both sides are laid out across files, from their
+// positions.
+func compareLayout(declarations []DeclarationResult) *LayoutResult {
+	r := &LayoutResult{}
+
+	leftToRight := make(map[string]map[string]bool)
+	rightToLeft := make(map[string]map[string]bool)
+	addMapping := func(m map[string]map[string]bool, from string, to string) {
+		if m[from] == nil {
+			m[from] = make(map[string]bool)
+		}
+		m[from][to] = true
+	}
+
+	for _, d := range declarations {
+		if strings.HasPrefix(d.Key, "import ") || !d.LeftPos.IsValid() || !d.RightPos.IsValid() {
+			continue
+		}
+
+		leftFile, rightFile := filepath.Base(d.LeftPos.Filename), filepath.Base(d.RightPos.Filename)
+		addMapping(leftToRight, leftFile, rightFile)
+		addMapping(rightToLeft, rightFile, leftFile)
+		if leftFile != rightFile {
+			r.Moves = append(r.Moves, DeclarationMove{Key: d.Key, LeftFile: leftFile, RightFile: rightFile})
+		}
+	}
+
+	r.Splits = fileMappings(leftToRight, func(from string, to []string) FileMapping {
+		return FileMapping{LeftFiles: []string{from}, RightFiles: to}
+	})
+	r.Merges = fileMappings(rightToLeft, func(from string, to []string) FileMapping {
+		return FileMapping{LeftFiles: to, RightFiles: []string{from}}
+	})
+
+	return r
+}
+
+// Build a FileMapping with newMapping for each file in m, sorted by name, whose declarations are
+// found in more than one file on the other side.
+func fileMappings(m map[string]map[string]bool, newMapping func(from string, to []string) FileMapping) []FileMapping {
+	var froms []string
+	for from := range m {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+
+	var mappings []FileMapping
+	for _, from := range froms {
+		if len(m[from]) < 2 {
+			continue
+		}
+
+		var to []string
+		for name := range m[from] {
+			to = append(to, name)
+		}
+		sort.Strings(to)
+		mappings = append(mappings, newMapping(from, to))
+	}
+	return mappings
+}
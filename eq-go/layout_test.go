@@ -0,0 +1,72 @@
+package eqgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareLayout(t *testing.T) {
+	testCases := []struct {
+		name        string
+		left, right map[string]string
+		want        LayoutResult
+	}{
+		{
+			name:  "same layout",
+			left:  map[string]string{"a.go": "package p\nfunc F() {}\n", "b.go": "package p\ntype T int\n"},
+			right: map[string]string{"b.go": "package p\ntype T int\n", "a.go": "package p\nfunc F() {}\n"},
+			want:  LayoutResult{},
+		},
+		{
+			name:  "declaration moved",
+			left:  map[string]string{"types.go": "package p\ntype T int\ntype U int\n", "models.go": "package p\n"},
+			right: map[string]string{"types.go": "package p\ntype U int\n", "models.go": "package p\ntype T int\n"},
+			want: LayoutResult{
+				Moves:  []DeclarationMove{{Key: "type T", LeftFile: "types.go", RightFile: "models.go"}},
+				Splits: []FileMapping{{LeftFiles: []string{"types.go"}, RightFiles: []string{"models.go", "types.go"}}},
+			},
+		},
+		{
+			name:  "files merged",
+			left:  map[string]string{"a.go": "package p\nimport \"fmt\"\nvar A = fmt.Sprint\n", "b.go": "package p\nimport \"fmt\"\nvar (\n\tB = fmt.Sprint\n\tC = 1\n)\n"},
+			right: map[string]string{"all.go": "package p\nimport \"fmt\"\nvar (\n\tA = fmt.Sprint\n\tB = fmt.Sprint\n\tC = 1\n)\n"},
+			want: LayoutResult{
+				Moves: []DeclarationMove{
+					{Key: "var A", LeftFile: "a.go", RightFile: "all.go"},
+					{Key: "var B", LeftFile: "b.go", RightFile: "all.go"},
+					{Key: "var C", LeftFile: "b.go", RightFile: "all.go"},
+				},
+				Merges: []FileMapping{{LeftFiles: []string{"a.go", "b.go"}, RightFiles: []string{"all.go"}}},
+			},
+		},
+		{
+			name:  "init function moved",
+			left:  map[string]string{"a.go": "package p\nfunc init() { a() }\n", "b.go": "package p\nfunc init() { b() }\n"},
+			right: map[string]string{"a.go": "package p\nfunc init() { b() }\n", "b.go": "package p\nfunc init() { a() }\n"},
+			want: LayoutResult{
+				Moves: []DeclarationMove{
+					{Key: "func init", LeftFile: "a.go", RightFile: "b.go"},
+					{Key: "func init#2", LeftFile: "b.go", RightFile: "a.go"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, fsetA := parseTestFiles(t, tc.left)
+			b, fsetB := parseTestFiles(t, tc.right)
+
+			r, err := ComparePackageFiles(a, fsetA, b, fsetB, &Options{ReportLayout: true, FlattenDeclarations: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !r.Equivalent {
+				t.Fatalf("packages not equivalent:\n%s", r.Format(nil))
+			}
+			if !reflect.DeepEqual(*r.Layout, tc.want) {
+				t.Errorf("Layout == %+v, want %+v", *r.Layout, tc.want)
+			}
+		})
+	}
+}
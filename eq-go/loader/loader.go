@@ -0,0 +1,210 @@
+// Package loader loads the Go packages in a directory for comparison with eq-go, selecting files
+// by build constraints the same way the go command does.
+package loader
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Config selects which files of a directory are loaded. The zero value selects files for the host
+// platform with no extra build tags, as build.Default does.
+type Config struct {
+	// Target operating system and architecture, e.g. "linux" and "amd64". Empty values default to
+	// those of build.Default.
+	GOOS, GOARCH string
+
+	// Additional build tags to consider satisfied.
+	Tags []string
+
+	// Whether files using cgo are selected. Defaults to the value in build.Default.
+	CgoEnabled *bool
+}
+
+// Packages holds the packages found in a single directory.
+type Packages struct {
+	// Directory the packages were loaded from.
+	Dir string
+
+	// File set which holds the positions of every file loaded.
+	Fset *token.FileSet
+
+	// Name of the library package. The external test package, if any, is named Name + "_test".
+	Name string
+
+	// Files of the library package, i.e. the non-test Go files (including cgo files), sorted by
+	// filename. Empty if the directory only contains test files.
+	Files []*ast.File
+
+	// In-package test files, i.e. _test.go files in the same package as the library, sorted by
+	// filename.
+	TestFiles []*ast.File
+
+	// Files of the external test package, i.e. _test.go files in package <name>_test, sorted by
+	// filename.
+	ExternalTestFiles []*ast.File
+}
+
+// Load loads the packages in dir for the host platform.
+func Load(dir string) (*Packages, error) {
+	return (&Config{}).Load(dir)
+}
+
+// Load loads the packages in dir, selecting the files which match c. Package names are taken from
+// the sources. Directories without any matching Go files, containing several non-test packages,
+// or containing files which fail to parse cause an error to be returned.
+func (c *Config) Load(dir string) (*Packages, error) {
+	return c.load(dir, nil)
+}
+
+// LoadFiles loads the packages made up of files for the host platform, as for Load.
+func LoadFiles(dir string, files map[string][]byte) (*Packages, error) {
+	return (&Config{}).LoadFiles(dir, files)
+}
+
+// LoadFiles loads the packages made up of files, selecting the files which match c as for Load,
+// without reading anything from disk. files maps the base name of each file to its contents, e.g.
+// as read from a version control system. dir is used only to name the files, e.g. in positions.
+func (c *Config) LoadFiles(dir string, files map[string][]byte) (*Packages, error) {
+	if files == nil {
+		files = make(map[string][]byte)
+	}
+	return c.load(dir, files)
+}
+
+// Load the packages in dir, from files if not nil or else from disk.
+func (c *Config) load(dir string, files map[string][]byte) (*Packages, error) {
+	ctxt := c.context()
+	if files != nil {
+		useFiles(&ctxt, dir, files)
+	}
+	bp, err := ctxt.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Packages{
+		Dir:  dir,
+		Fset: token.NewFileSet(),
+		Name: bp.Name,
+	}
+
+	library := append(append([]string{}, bp.GoFiles...), bp.CgoFiles...)
+	if p.Files, err = parseFiles(p.Fset, dir, bp.Name, library, files); err != nil {
+		return nil, err
+	}
+	if p.TestFiles, err = parseFiles(p.Fset, dir, bp.Name, bp.TestGoFiles, files); err != nil {
+		return nil, err
+	}
+	if p.ExternalTestFiles, err = parseFiles(p.Fset, dir, bp.Name+"_test", bp.XTestGoFiles, files); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// String describes the configuration as GOOS/GOARCH followed by any build tags, e.g.
+// "linux/amd64" or "windows/arm64 [foo bar]". Unset fields are described by their defaults.
+func (c Config) String() string {
+	ctxt := c.context()
+	s := ctxt.GOOS + "/" + ctxt.GOARCH
+	if len(c.Tags) > 0 {
+		s += fmt.Sprintf(" %v", c.Tags)
+	}
+	return s
+}
+
+func (c Config) context() build.Context {
+	ctxt := build.Default
+	if c.GOOS != "" {
+		ctxt.GOOS = c.GOOS
+	}
+	if c.GOARCH != "" {
+		ctxt.GOARCH = c.GOARCH
+	}
+	if c.CgoEnabled != nil {
+		ctxt.CgoEnabled = *c.CgoEnabled
+	}
+	ctxt.BuildTags = append([]string{}, c.Tags...)
+	return ctxt
+}
+
+// Make ctxt find the files of dir in files rather than on disk.
+func useFiles(ctxt *build.Context, dir string, files map[string][]byte) {
+	dir = filepath.Clean(dir)
+	ctxt.IsDir = func(path string) bool {
+		return filepath.Clean(path) == dir
+	}
+	ctxt.ReadDir = func(path string) ([]os.FileInfo, error) {
+		if filepath.Clean(path) != dir {
+			return nil, &os.PathError{Op: "readdir", Path: path, Err: os.ErrNotExist}
+		}
+
+		var names []string
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var infos []os.FileInfo
+		for _, name := range names {
+			infos = append(infos, fileInfo{name: name, size: int64(len(files[name]))})
+		}
+		return infos, nil
+	}
+	ctxt.OpenFile = func(path string) (io.ReadCloser, error) {
+		src, ok := files[filepath.Base(path)]
+		if !ok || filepath.Dir(path) != dir {
+			return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+		}
+		return ioutil.NopCloser(bytes.NewReader(src)), nil
+	}
+}
+
+// Describes a file of an in-memory directory.
+type fileInfo struct {
+	name string
+	size int64
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) Mode() os.FileMode  { return 0444 }
+func (fi fileInfo) ModTime() time.Time { return time.Time{} }
+func (fi fileInfo) IsDir() bool        { return false }
+func (fi fileInfo) Sys() interface{}   { return nil }
+
+// Parse the named files of dir, which must belong to the named package, reading them from files if
+// not nil or else from disk. The files are returned sorted by filename.
+func parseFiles(fset *token.FileSet, dir string, name string, filenames []string, files map[string][]byte) ([]*ast.File, error) {
+	filenames = append([]string{}, filenames...)
+	sort.Strings(filenames)
+
+	var parsed []*ast.File
+	for _, f := range filenames {
+		path := filepath.Join(dir, f)
+		var src interface{}
+		if files != nil {
+			src = files[f]
+		}
+		file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if file.Name.Name != name {
+			return nil, fmt.Errorf("%s: found package %s, want %s", path, file.Name.Name, name)
+		}
+		parsed = append(parsed, file)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,150 @@
+package loader
+
+import (
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "loader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func fileNames(p *Packages, files []*ast.File) []string {
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(p.Fset.Position(f.Pos()).Filename))
+	}
+	return names
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeDir(t, map[string]string{
+		"p.go":         "package p\n",
+		"p_linux.go":   "package p\n",
+		"p_windows.go": "package p\n",
+		"tagged.go":    "//go:build foo\n// +build foo\n\npackage p\n",
+		"ignored.go":   "//go:build ignore\n// +build ignore\n\npackage main\n",
+		"p_test.go":    "package p\n",
+		"x_test.go":    "package p_test\n",
+		"notes.txt":    "not go",
+	})
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		config           Config
+		wantLibrary      []string
+		wantTests        []string
+		wantExternalTest []string
+	}{
+		{
+			config:           Config{GOOS: "linux", GOARCH: "amd64"},
+			wantLibrary:      []string{"p.go", "p_linux.go"},
+			wantTests:        []string{"p_test.go"},
+			wantExternalTest: []string{"x_test.go"},
+		},
+		{
+			config:           Config{GOOS: "windows", GOARCH: "amd64", Tags: []string{"foo"}},
+			wantLibrary:      []string{"p.go", "p_windows.go", "tagged.go"},
+			wantTests:        []string{"p_test.go"},
+			wantExternalTest: []string{"x_test.go"},
+		},
+	}
+	for _, c := range testCases {
+		got, err := c.config.Load(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.Name != "p" {
+			t.Errorf("Load() package name == %q, want %q", got.Name, "p")
+		}
+		if names := fileNames(got, got.Files); !equalStrings(names, c.wantLibrary) {
+			t.Errorf("%+v: library files == %v, want %v", c.config, names, c.wantLibrary)
+		}
+		if names := fileNames(got, got.TestFiles); !equalStrings(names, c.wantTests) {
+			t.Errorf("%+v: test files == %v, want %v", c.config, names, c.wantTests)
+		}
+		if names := fileNames(got, got.ExternalTestFiles); !equalStrings(names, c.wantExternalTest) {
+			t.Errorf("%+v: external test files == %v, want %v", c.config, names, c.wantExternalTest)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	testCases := []map[string]string{
+		{"p.go": "package p\nfunc {"},
+		{"p.go": "package p\n", "q.go": "package q\n"},
+		{"notes.txt": "not go"},
+	}
+	for _, files := range testCases {
+		dir := writeDir(t, files)
+		if _, err := Load(dir); err == nil {
+			t.Errorf("Load(%v) returned no error", files)
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+func TestLoadFiles(t *testing.T) {
+	files := map[string][]byte{
+		"p.go":         []byte("package p\n"),
+		"p_linux.go":   []byte("package p\n"),
+		"p_windows.go": []byte("package p\n"),
+		"p_test.go":    []byte("package p\n"),
+		"x_test.go":    []byte("package p_test\n"),
+		"notes.txt":    []byte("not go"),
+	}
+
+	got, err := (&Config{GOOS: "linux", GOARCH: "amd64"}).LoadFiles("HEAD:p/", files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"p.go", "p_linux.go"}; !equalStrings(fileNames(got, got.Files), want) {
+		t.Errorf("library files == %v, want %v", fileNames(got, got.Files), want)
+	}
+	if want := []string{"p_test.go"}; !equalStrings(fileNames(got, got.TestFiles), want) {
+		t.Errorf("test files == %v, want %v", fileNames(got, got.TestFiles), want)
+	}
+	if want := []string{"x_test.go"}; !equalStrings(fileNames(got, got.ExternalTestFiles), want) {
+		t.Errorf("external test files == %v, want %v", fileNames(got, got.ExternalTestFiles), want)
+	}
+	for _, f := range got.Files {
+		if pos := got.Fset.Position(f.Pos()); filepath.Dir(pos.Filename) != "HEAD:p" {
+			t.Errorf("library file positioned in %s, want a file in HEAD:p", pos.Filename)
+		}
+	}
+
+	if _, err := LoadFiles("p", map[string][]byte{"p.go": []byte("package p\nfunc {")}); err == nil {
+		t.Error("LoadFiles() with a syntax error returned no error")
+	}
+	if _, err := LoadFiles("p", nil); err == nil {
+		t.Error("LoadFiles() with no files returned no error")
+	}
+}
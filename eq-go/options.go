@@ -0,0 +1,94 @@
+package eqgo
+
+import (
+	"go/ast"
+)
+
+// Options configures optional normalizations which relax the notion of equivalence used when
+// comparing packages or files. The zero value (or a nil *Options) performs the same comparison as
+// PackagesEquivalent and FilesEquivalent.
+type Options struct {
+	// Evaluate constant expressions made up of literals and compare literals by value rather than
+	// by spelling, so that e.g. 0x10 and 16, 1<<3 and 8, `raw` and "raw", or "a"+"b" and "ab" are
+	// equivalent. Where a numeric type is spelled out (a typed var/const declaration or a
+	// conversion), rune, integer and float literals of the same value are also equivalent, e.g.
+	// `var r rune = 'a'` and `var r rune = 97`.
+	FoldConstants bool
+
+	// Remove parentheses which don't affect how the code parses, so that e.g. `x = (y)` and
+	// `x = y`, `var p (*T)` and `var p *T`, or `(T)(x)` and `T(x)` are equivalent.
+	NormalizeParentheses bool
+
+	// Flatten grouped and multi-name declarations, so that e.g. `var (a int; b int)`,
+	// `var a int; var b int` and `var a, b int` are equivalent, as are struct fields `X, Y int` and
+	// `X int; Y int`, and parameters `(a, b int)` and `(a int, b int)`.
+	FlattenDeclarations bool
+
+	// Compare the methods and embedded interfaces of interface types as unordered sets keyed by
+	// method name, rather than as ordered lists.
+	UnorderedInterfaceMethods bool
+
+	// When UnorderedInterfaceMethods is set, also replace embedded interfaces declared in the same
+	// package with their method sets, so that e.g. `interface { Reader; Close() error }` and
+	// `interface { Read(p []byte) (int, error); Close() error }` are equivalent.
+	ExpandEmbeddedInterfaces bool
+
+	// Type-check both sides with go/types and compare what identifiers resolve to, the types of
+	// expressions and the values of constant expressions, rather than only their syntax. For
+	// example, `import str "strings"; str.ToUpper` and `import "strings"; strings.ToUpper` are
+	// equivalent, as are constant expressions of the same type and value such as `N * 2` and `8`
+	// (where N is 4). Imports are type-checked from source, using the local GOROOT and module cache.
+	// Packages which fail to type-check cause the comparison to return an error.
+	TypeCheck bool
+
+	// Compare asymmetrically, requiring only that the left side be contained in the right: every
+	// top-level declaration on the left must have an equivalent declaration on the right, while
+	// declarations which only exist on the right are reported in Result.Additions rather than
+	// making the inputs non-equivalent. In this mode, Equivalent means "is a subset of".
+	Subset bool
+
+	// Also compare test files, when comparing packages loaded with the loader package (e.g. with
+	// CompareLoadedPackages or CompareDirs). In-package _test.go files are compared as part of the
+	// library package, and external <name>_test packages are compared separately. The expected
+	// output of examples is compared as well, although comments are otherwise ignored.
+	IncludeTests bool
+
+	// Also report which top-level declarations are in differently named files on each side, and
+	// which files were split or merged, in Result.Layout. Moving declarations between files never
+	// affects equivalence, but can matter for build constraints, initialization order or code
+	// ownership.
+	ReportLayout bool
+
+	// Also require package-level variable initializers with side effects, and init functions, to
+	// run in the same order on both sides. Declarations are otherwise compared regardless of their
+	// order, although the order of files, the order of declarations and the dependencies between
+	// initializers determine the order in which they run. The initialization order is computed with
+	// go/types as defined by the spec, with files taken in filename order, so the packages must
+	// type-check, as for TypeCheck.
+	InitOrder bool
+}
+
+// Apply the normalizations enabled by opts to f in place.
+func normalizeFile(f *ast.File, opts *Options) {
+	if opts == nil {
+		return
+	}
+
+	if opts.FlattenDeclarations {
+		flattenDeclarations(f)
+	}
+
+	if opts.UnorderedInterfaceMethods {
+		normalizeInterfaces(f, opts.ExpandEmbeddedInterfaces)
+	}
+
+	if opts.NormalizeParentheses {
+		normalizeParentheses(f)
+	}
+
+	if opts.FoldConstants {
+		foldConstants(f)
+	}
+
+	pruneUnresolved(f)
+}
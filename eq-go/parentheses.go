@@ -0,0 +1,150 @@
+package eqgo
+
+import (
+	"go/ast"
+)
+
+// Helpers to remove redundant parentheses.
+//
+// The structure of a syntax tree already reflects operator precedence, e.g. `(a + b) * c` parses to
+// a multiplication whose left operand is an addition, so a ParenExpr node never changes how the
+// expression it wraps is evaluated. Parentheses are therefore removed everywhere except in the few
+// places where the source could not be parsed back into the same tree without them:
+//
+//   - around a function or channel type being converted to, e.g. `(func())(x)` or `(<-chan int)(c)`
+//   - around a receive-only channel type used as the element of a bidirectional channel type, e.g.
+//     `chan (<-chan int)`
+//   - around a composite literal in the header of an if, for or switch statement, e.g.
+//     `if x == (T{}) {`
+//
+// Printing a tree whose parentheses have been removed (e.g. with go/printer) reinserts any
+// parentheses which operator precedence requires.
+
+func normalizeParentheses(f *ast.File) {
+	keep := make(map[*ast.ParenExpr]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		for _, x := range controlClauseExpressions(n) {
+			markControlClauseParentheses(x, keep)
+		}
+		return true
+	})
+
+	rewriteExpressions(f, func(x ast.Expr, parent ast.Node) ast.Expr {
+		paren, ok := x.(*ast.ParenExpr)
+		if !ok || keep[paren] || parenthesesRequired(paren, parent) {
+			return x
+		}
+		return paren.X
+	})
+}
+
+// Report whether the parentheses around an expression with the given parent are required to parse.
+func parenthesesRequired(x *ast.ParenExpr, parent ast.Node) bool {
+	switch p := parent.(type) {
+	case *ast.CallExpr:
+		if p.Fun != ast.Expr(x) {
+			return false
+		}
+		switch x.X.(type) {
+		case *ast.FuncType, *ast.ChanType:
+			return true
+		}
+
+	case *ast.ChanType:
+		if inner, ok := x.X.(*ast.ChanType); ok {
+			return p.Dir == ast.SEND|ast.RECV && inner.Dir == ast.RECV
+		}
+	}
+	return false
+}
+
+// Nodes between the keyword and the opening brace of an if, for or switch statement.
+func controlClauseExpressions(n ast.Node) []ast.Node {
+	var nodes []ast.Node
+	add := func(x ast.Node) {
+		if x != nil {
+			nodes = append(nodes, x)
+		}
+	}
+
+	switch s := n.(type) {
+	case *ast.IfStmt:
+		add(s.Init)
+		add(s.Cond)
+	case *ast.ForStmt:
+		add(s.Init)
+		add(s.Cond)
+		add(s.Post)
+	case *ast.RangeStmt:
+		add(s.Key)
+		add(s.Value)
+		add(s.X)
+	case *ast.SwitchStmt:
+		add(s.Init)
+		add(s.Tag)
+	case *ast.TypeSwitchStmt:
+		add(s.Init)
+		add(s.Assign)
+	}
+	return nodes
+}
+
+// Mark the parentheses which enclose a composite literal of the form `TypeName{...}` within a
+// control clause. Without them, the literal's opening brace would be parsed as the start of the
+// statement's block.
+func markControlClauseParentheses(root ast.Node, keep map[*ast.ParenExpr]bool) {
+	var stack []ast.Node
+	ast.Inspect(root, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok || !isTypeName(lit.Type) {
+			return true
+		}
+
+		for i := len(stack) - 2; i >= 0; i-- {
+			child := stack[i+1]
+			switch p := stack[i].(type) {
+			case *ast.ParenExpr:
+				keep[p] = true
+				return true
+			case *ast.CallExpr:
+				if child != ast.Node(p.Fun) {
+					return true
+				}
+			case *ast.IndexExpr:
+				if child != ast.Node(p.X) {
+					return true
+				}
+			case *ast.SliceExpr:
+				if child != ast.Node(p.X) {
+					return true
+				}
+			case *ast.CompositeLit:
+				if child != ast.Node(p.Type) {
+					return true
+				}
+			case *ast.FuncLit:
+				if child == ast.Node(p.Body) {
+					return true
+				}
+			}
+		}
+		return true
+	})
+}
+
+func isTypeName(x ast.Expr) bool {
+	switch t := x.(type) {
+	case *ast.Ident:
+		return true
+	case *ast.SelectorExpr:
+		_, ok := t.X.(*ast.Ident)
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package eqgo
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestNormalizeParentheses(t *testing.T) {
+	testCases := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{a: "func f() { x = (y) }", b: "func f() { x = y }", want: true},
+		{a: "var x = ((a + b)) * c", b: "var x = (a + b) * c", want: true},
+		{a: "var x = a + (b * c)", b: "var x = a + b * c", want: true},
+		{a: "var x = (a + b) * c", b: "var x = a + b * c", want: false},
+		{a: "var x = a - (b - c)", b: "var x = a - b - c", want: false},
+		{a: "var p (*T)", b: "var p *T", want: true},
+		{a: "var x = (T)(y)", b: "var x = T(y)", want: true},
+		{a: "var x = (*T)(y)", b: "var x = *T(y)", want: false},
+		{a: "var x = (*p).f", b: "var x = *p.f", want: false},
+		{a: "var x = f((a), (b))", b: "var x = f(a, b)", want: true},
+		{a: "var x = -(a)", b: "var x = -a", want: true},
+		{a: "var x = -(a + b)", b: "var x = -a + b", want: false},
+		{a: "func f() { if (x) { } }", b: "func f() { if x { } }", want: true},
+		{a: "func f() { if x == (T{}) { } }", b: "func f() { if (x == (T{})) { } }", want: true},
+		{a: "var c chan (<-chan int)", b: "var c chan<- (chan int)", want: false},
+	}
+	for _, c := range testCases {
+		fset := token.NewFileSet()
+		fileA, err := parser.ParseFile(fset, "a.go", "package p; "+c.a, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileB, err := parser.ParseFile(fset, "b.go", "package p; "+c.b, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := CompareFiles(fileA, fset, fileB, fset, &Options{NormalizeParentheses: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Equivalent != c.want {
+			t.Errorf("CompareFiles(%q, %q) == %t, want %t\n%s", c.a, c.b, got.Equivalent, c.want, got.Format(nil))
+		}
+	}
+}
+
+func TestNormalizeParenthesesKeepsRequiredParentheses(t *testing.T) {
+	testCases := []struct {
+		src  string
+		want int
+	}{
+		{src: "var x = (func())(y)", want: 1},
+		{src: "var x = (<-chan int)(y)", want: 1},
+		{src: "var c chan (<-chan int)", want: 1},
+		{src: "func f() { if x == (T{}) { } }", want: 1},
+		{src: "func f() { if x == (pkg.T{}) { } }", want: 1},
+		{src: "func f() { if f(T{}) { } }", want: 0},
+		{src: "func f() { x = (T{}) }", want: 0},
+		{src: "func f() { if x == ([]int{}) == nil { } }", want: 0},
+	}
+	for _, c := range testCases {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "a.go", "package p; "+c.src, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		normalizeParentheses(file)
+
+		got := 0
+		ast.Inspect(file, func(n ast.Node) bool {
+			if _, ok := n.(*ast.ParenExpr); ok {
+				got++
+			}
+			return true
+		})
+		if got != c.want {
+			t.Errorf("normalizeParentheses(%q) left %d parentheses, want %d", c.src, got, c.want)
+		}
+	}
+}
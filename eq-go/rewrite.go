@@ -0,0 +1,170 @@
+package eqgo
+
+import (
+	"go/ast"
+	"reflect"
+)
+
+// Helpers to rewrite syntax trees in place ahead of a comparison.
+
+var (
+	exprType      = reflect.TypeOf((*ast.Expr)(nil)).Elem()
+	exprSliceType = reflect.TypeOf([]ast.Expr(nil))
+	nodeType      = reflect.TypeOf((*ast.Node)(nil)).Elem()
+)
+
+// Replace every expression below n with the result of calling f on it. Expressions are visited
+// innermost first, so f sees an expression only after all of its subexpressions have been
+// rewritten. parent is the node which holds the expression.
+//
+// Only fields declared with an interface type (ast.Expr or []ast.Expr) can be replaced; expressions
+// held by fields of a concrete type, such as a FuncDecl's *ast.Ident name, are visited but not
+// replaced.
+func rewriteExpressions(n ast.Node, f func(x ast.Expr, parent ast.Node) ast.Expr) {
+	v := reflect.ValueOf(n)
+	if !v.IsValid() || v.Kind() != reflect.Ptr || v.IsNil() {
+		return
+	}
+
+	s := v.Elem()
+	if s.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < s.NumField(); i++ {
+		field := s.Field(i)
+
+		switch {
+		case field.Type() == exprType:
+			if field.IsNil() {
+				continue
+			}
+			x := field.Interface().(ast.Expr)
+			rewriteExpressions(x, f)
+			field.Set(reflect.ValueOf(f(x, n)))
+
+		case field.Type() == exprSliceType:
+			for j := 0; j < field.Len(); j++ {
+				elem := field.Index(j)
+				if elem.IsNil() {
+					continue
+				}
+				x := elem.Interface().(ast.Expr)
+				rewriteExpressions(x, f)
+				elem.Set(reflect.ValueOf(f(x, n)))
+			}
+
+		case field.Type().Implements(nodeType):
+			if field.IsNil() {
+				continue
+			}
+			rewriteExpressions(field.Interface().(ast.Node), f)
+
+		case field.Kind() == reflect.Slice && field.Type().Elem().Implements(nodeType):
+			for j := 0; j < field.Len(); j++ {
+				elem := field.Index(j)
+				if elem.IsNil() {
+					continue
+				}
+				rewriteExpressions(elem.Interface().(ast.Node), f)
+			}
+		}
+	}
+}
+
+// Return a deep copy of the syntax tree rooted at n. Scopes and objects, which are shared between
+// many nodes, are not copied.
+func cloneNode(n ast.Node) ast.Node {
+	if n == nil {
+		return nil
+	}
+	return cloneValue(reflect.ValueOf(n), nil).Interface().(ast.Node)
+}
+
+// Return a copy of the syntax tree rooted at n, as cloneNode, to be normalized or sorted by the
+// comparison in progress without modifying the caller's tree. Type information of the comparison
+// is recorded for the original nodes, so each copied node is mapped back to its original.
+func cloneForComparison(n ast.Node) ast.Node {
+	if n == nil {
+		return nil
+	}
+	if clonedNodes == nil {
+		clonedNodes = make(map[ast.Node]ast.Node)
+	}
+	return cloneValue(reflect.ValueOf(n), clonedNodes).Interface().(ast.Node)
+}
+
+// Node which n was copied from by cloneForComparison, or n itself if it isn't a copy.
+func originalNode(n ast.Node) ast.Node {
+	if o, ok := clonedNodes[n]; ok {
+		return o
+	}
+	return n
+}
+
+// Copy v, recording each copied node's original in originals if it is non-nil.
+func cloneValue(v reflect.Value, originals map[ast.Node]ast.Node) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		switch v.Interface().(type) {
+		case *ast.Object, *ast.Scope:
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(cloneValue(v.Elem(), originals))
+		if n, ok := c.Interface().(ast.Node); ok && originals != nil {
+			originals[n] = originalNode(v.Interface().(ast.Node))
+		}
+		return c
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(cloneValue(v.Elem(), originals))
+		return c
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(cloneValue(v.Index(i), originals))
+		}
+		return c
+
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			c.Field(i).Set(cloneValue(v.Field(i), originals))
+		}
+		return c
+	}
+
+	return v
+}
+
+// Remove identifiers which are no longer part of the tree from a file's list of unresolved
+// identifiers, e.g. after iota has been replaced by its value.
+func pruneUnresolved(f *ast.File) {
+	present := make(map[*ast.Ident]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			present[ident] = true
+		}
+		return true
+	})
+
+	var unresolved []*ast.Ident
+	for _, ident := range f.Unresolved {
+		if present[ident] {
+			unresolved = append(unresolved, ident)
+		}
+	}
+	f.Unresolved = unresolved
+}
@@ -0,0 +1,275 @@
+package eqgo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// Helpers to compute a normalized tree-edit-distance similarity between two syntax trees.
+//
+// Syntax trees are reduced to ordered trees of string labels (the node's type plus any identifying
+// value such as an identifier's name or an operator) and compared with the Zhang-Shasha tree edit
+// distance algorithm, using unit costs for insertions, deletions and relabelings.
+
+// Upper bound on the size of the distance table, above which a cheaper approximation is used.
+const maxTreeEditDistanceCells = 1 << 22
+
+type labelTree struct {
+	// Node labels in postorder.
+	labels []string
+
+	// Postorder index of each node's leftmost leaf descendant.
+	leftmost []int
+
+	// Postorder indices of the nodes which have a left sibling, plus the root, in ascending order.
+	keyroots []int
+}
+
+func newLabelTree(n ast.Node) *labelTree {
+	t := &labelTree{}
+	if n == nil {
+		return t
+	}
+
+	var starts []int
+	var nodes []ast.Node
+	ast.Inspect(n, func(x ast.Node) bool {
+		switch x.(type) {
+		case *ast.CommentGroup, *ast.Comment:
+			return false
+		}
+
+		if x != nil {
+			starts = append(starts, len(t.labels))
+			nodes = append(nodes, x)
+			return true
+		}
+
+		last := len(nodes) - 1
+		t.labels = append(t.labels, nodeLabel(nodes[last]))
+		t.leftmost = append(t.leftmost, starts[last])
+		starts = starts[:last]
+		nodes = nodes[:last]
+		return true
+	})
+
+	seen := make(map[int]bool)
+	for i := len(t.labels) - 1; i >= 0; i-- {
+		if !seen[t.leftmost[i]] {
+			seen[t.leftmost[i]] = true
+			t.keyroots = append(t.keyroots, i)
+		}
+	}
+	for i, j := 0, len(t.keyroots)-1; i < j; i, j = i+1, j-1 {
+		t.keyroots[i], t.keyroots[j] = t.keyroots[j], t.keyroots[i]
+	}
+
+	return t
+}
+
+func (t *labelTree) size() int {
+	return len(t.labels)
+}
+
+func nodeLabel(n ast.Node) string {
+	switch x := n.(type) {
+	case *ast.Ident:
+		return "Ident " + normalizePackageName(x.Name)
+	case *ast.BasicLit:
+		return fmt.Sprintf("BasicLit %s %s", x.Kind, normalizePackageName(x.Value))
+	case *ast.BinaryExpr:
+		return "BinaryExpr " + x.Op.String()
+	case *ast.UnaryExpr:
+		return "UnaryExpr " + x.Op.String()
+	case *ast.AssignStmt:
+		return "AssignStmt " + x.Tok.String()
+	case *ast.IncDecStmt:
+		return "IncDecStmt " + x.Tok.String()
+	case *ast.BranchStmt:
+		return "BranchStmt " + x.Tok.String()
+	case *ast.RangeStmt:
+		return "RangeStmt " + x.Tok.String()
+	case *ast.GenDecl:
+		return "GenDecl " + x.Tok.String()
+	case *ast.ChanType:
+		return fmt.Sprintf("ChanType %d", x.Dir)
+	case *ast.SliceExpr:
+		return fmt.Sprintf("SliceExpr %t", x.Slice3)
+	case *ast.EmptyStmt:
+		return fmt.Sprintf("EmptyStmt %t", x.Implicit)
+	}
+	return fmt.Sprintf("%T", n)[len("*ast."):]
+}
+
+// Compute the tree edit distance between two label trees.
+func treeEditDistance(a *labelTree, b *labelTree) int {
+	n, m := a.size(), b.size()
+	if n == 0 || m == 0 {
+		return n + m
+	}
+	if n*m > maxTreeEditDistanceCells {
+		return approximateTreeEditDistance(a, b)
+	}
+
+	treeDist := make([][]int, n)
+	for i := range treeDist {
+		treeDist[i] = make([]int, m)
+	}
+
+	for _, i := range a.keyroots {
+		for _, j := range b.keyroots {
+			forestDistance(a, b, i, j, treeDist)
+		}
+	}
+
+	return treeDist[n-1][m-1]
+}
+
+// Fill in treeDist for the subtrees rooted at keyroots i and j.
+func forestDistance(a *labelTree, b *labelTree, i int, j int, treeDist [][]int) {
+	li, lj := a.leftmost[i], b.leftmost[j]
+	rows, cols := i-li+2, j-lj+2
+
+	forestDist := make([][]int, rows)
+	for x := range forestDist {
+		forestDist[x] = make([]int, cols)
+	}
+	for x := 1; x < rows; x++ {
+		forestDist[x][0] = forestDist[x-1][0] + 1
+	}
+	for y := 1; y < cols; y++ {
+		forestDist[0][y] = forestDist[0][y-1] + 1
+	}
+
+	for x := 1; x < rows; x++ {
+		i1 := li + x - 1
+		for y := 1; y < cols; y++ {
+			j1 := lj + y - 1
+			del := forestDist[x-1][y] + 1
+			ins := forestDist[x][y-1] + 1
+
+			if a.leftmost[i1] == li && b.leftmost[j1] == lj {
+				relabel := forestDist[x-1][y-1]
+				if a.labels[i1] != b.labels[j1] {
+					relabel++
+				}
+				forestDist[x][y] = minInt(del, ins, relabel)
+				treeDist[i1][j1] = forestDist[x][y]
+				continue
+			}
+
+			p := a.leftmost[i1] - li
+			q := b.leftmost[j1] - lj
+			forestDist[x][y] = minInt(del, ins, forestDist[p][q]+treeDist[i1][j1])
+		}
+	}
+}
+
+// Approximate the tree edit distance of two large trees by the number of labels which cannot be
+// paired up between them, ignoring tree structure.
+func approximateTreeEditDistance(a *labelTree, b *labelTree) int {
+	counts := make(map[string]int)
+	for _, l := range a.labels {
+		counts[l]++
+	}
+
+	common := 0
+	for _, l := range b.labels {
+		if counts[l] > 0 {
+			counts[l]--
+			common++
+		}
+	}
+
+	return maxInt(a.size(), b.size()) - common
+}
+
+func minInt(x int, ys ...int) int {
+	for _, y := range ys {
+		if y < x {
+			x = y
+		}
+	}
+	return x
+}
+
+func maxInt(x int, ys ...int) int {
+	for _, y := range ys {
+		if y > x {
+			x = y
+		}
+	}
+	return x
+}
+
+// Describe each pair of matched declarations and, if measure is set, compute the per-declaration
+// similarities and the overall similarity of the two sides. Otherwise similarities are left 0.
+//
+// The overall similarity is 1 - (total edit distance / total size), where a declaration which only
+// exists on one side contributes its full size to both the edit distance and the size.
+func declarationSimilarities(pairs []declarationPair, fsetA *token.FileSet, fsetB *token.FileSet, measure bool) ([]DeclarationResult, float64) {
+	var results []DeclarationResult
+	totalDist, totalSize := 0, 0
+
+	for _, pair := range pairs {
+		left, right := pair.left, pair.right
+		r := DeclarationResult{Key: pair.key}
+		if left != nil {
+			r.LeftPos = position(fsetA, left.decl.Pos())
+		}
+		if right != nil {
+			r.RightPos = position(fsetB, right.decl.Pos())
+		}
+		if !measure {
+			results = append(results, r)
+			continue
+		}
+
+		var leftTree, rightTree *labelTree
+		if left != nil {
+			leftTree = newLabelTree(left.decl)
+		} else {
+			leftTree = newLabelTree(nil)
+		}
+		if right != nil {
+			rightTree = newLabelTree(right.decl)
+		} else {
+			rightTree = newLabelTree(nil)
+		}
+
+		size := maxInt(leftTree.size(), rightTree.size())
+		dist := size
+		if left != nil && right != nil {
+			if cmp, _ := compareDecls(left.decl, right.decl); cmp == 0 {
+				dist = 0
+			} else {
+				// Declarations which differ only in ways the labels don't capture still count as
+				// one edit apart.
+				dist = maxInt(treeEditDistance(leftTree, rightTree), 1)
+			}
+		}
+
+		r.Similarity = similarity(dist, size)
+		results = append(results, r)
+
+		totalDist += dist
+		totalSize += size
+	}
+
+	return results, similarity(totalDist, totalSize)
+}
+
+func similarity(dist int, size int) float64 {
+	if size == 0 {
+		return 1
+	}
+	return 1 - float64(dist)/float64(size)
+}
+
+func position(fset *token.FileSet, pos token.Pos) token.Position {
+	if fset == nil {
+		return token.Position{}
+	}
+	return fset.Position(pos)
+}
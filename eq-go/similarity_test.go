@@ -0,0 +1,182 @@
+package eqgo
+
+import (
+	"go/parser"
+	"go/token"
+	"math"
+	"testing"
+)
+
+func TestTreeEditDistance(t *testing.T) {
+	testCases := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{
+			a:    "a + b",
+			b:    "a + b",
+			want: 0,
+		},
+		{
+			a:    "a + b",
+			b:    "a - b",
+			want: 1,
+		},
+		{
+			a:    "a + b",
+			b:    "a + c",
+			want: 1,
+		},
+		{
+			a:    "f(a)",
+			b:    "f(a, b)",
+			want: 1,
+		},
+		{
+			a:    "f(a, b)",
+			b:    "f(b)",
+			want: 1,
+		},
+		{
+			a:    "a",
+			b:    "(a)",
+			want: 1,
+		},
+		{
+			a:    "a + b*c",
+			b:    "x",
+			want: 5,
+		},
+	}
+	for _, c := range testCases {
+		exprA, err := parser.ParseExpr(c.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exprB, err := parser.ParseExpr(c.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := treeEditDistance(newLabelTree(exprA), newLabelTree(exprB))
+		if got != c.want {
+			t.Errorf("treeEditDistance(%s, %s) == %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestCompareFilesSimilarity(t *testing.T) {
+	testCases := []struct {
+		a              string
+		b              string
+		wantEquivalent bool
+		wantSimilarity float64
+		wantKeys       []string
+	}{
+		{
+			a:              "package p; func A() {}; var x = 1",
+			b:              "package p; var x = 1; func A() {}",
+			wantEquivalent: true,
+			wantSimilarity: 1,
+			wantKeys:       []string{"func A", "var x"},
+		},
+		{
+			a:              "package p; func A() {}",
+			b:              "package p; func A() {}; func B() {}",
+			wantEquivalent: false,
+			wantSimilarity: 0.5,
+			wantKeys:       []string{"func A", "func B"},
+		},
+		{
+			a:              "package p; import \"fmt\"; type T int; func (T) M() { fmt.Println(1) }",
+			b:              "package p; import \"fmt\"; type T int; func (T) M() { fmt.Println(2) }",
+			wantEquivalent: false,
+			wantSimilarity: 1 - 1.0/21,
+			wantKeys:       []string{"import \"fmt\"", "method T.M", "type T"},
+		},
+		{
+			a:              "package p; func init() { a() }; func init() { b() }",
+			b:              "package p; func init() { b() }; func init() { a() }",
+			wantEquivalent: true,
+			wantSimilarity: 1,
+			wantKeys:       []string{"func init", "func init#2"},
+		},
+	}
+	for _, c := range testCases {
+		fset := token.NewFileSet()
+		fileA, err := parser.ParseFile(fset, "a.go", c.a, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileB, err := parser.ParseFile(fset, "b.go", c.b, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := CompareFiles(fileA, fset, fileB, fset, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var gotKeys []string
+		for _, d := range got.Declarations {
+			gotKeys = append(gotKeys, d.Key)
+		}
+
+		if got.Equivalent != c.wantEquivalent ||
+			math.Abs(got.Similarity-c.wantSimilarity) > 1e-9 ||
+			!equalStringSlices(gotKeys, c.wantKeys) {
+			t.Errorf(
+				"CompareFiles(%q, %q) == (%t, %f, %v), want (%t, %f, %v)",
+				c.a,
+				c.b,
+				got.Equivalent,
+				got.Similarity,
+				gotKeys,
+				c.wantEquivalent,
+				c.wantSimilarity,
+				c.wantKeys,
+			)
+		}
+	}
+}
+
+func TestCompareFilesSimilarityOfReorderedDuplicates(t *testing.T) {
+	fset := token.NewFileSet()
+	fileA, err := parser.ParseFile(fset, "a.go", "package p; func init() { a() }; func init() { b() }; var x = 1", parser.AllErrors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileB, err := parser.ParseFile(fset, "b.go", "package p; func init() { b() }; func init() { a() }; var x = 2", parser.AllErrors)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := CompareFiles(fileA, fset, fileB, fset, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range got.Declarations {
+		want := 1.0
+		if d.Key == "var x" {
+			want = 1 - 1.0/4
+		}
+		if math.Abs(d.Similarity-want) > 1e-9 {
+			t.Errorf("similarity of %s == %f, want %f", d.Key, d.Similarity, want)
+		}
+	}
+}
+
+func equalStringSlices(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
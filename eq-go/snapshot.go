@@ -0,0 +1,184 @@
+package eqgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"strings"
+)
+
+// SnapshotVersion identifies the snapshot format written by WriteSnapshot. ReadSnapshot rejects
+// snapshots of any other version.
+const SnapshotVersion = 1
+
+// Snapshot is a serializable record of the canonical form of a file or package, as produced by
+// Canonicalize, which can later be compared against live code without keeping the original source.
+type Snapshot struct {
+	Version int `json:"version"`
+
+	// Name of the package.
+	Package string `json:"package"`
+
+	// Top-level declarations, in canonical order with imports first.
+	Declarations []SnapshotDeclaration `json:"declarations"`
+}
+
+// SnapshotDeclaration records a single top-level declaration of a Snapshot.
+type SnapshotDeclaration struct {
+	// Identifies the declaration within its package, as in DeclarationResult.
+	Key string `json:"key"`
+
+	// Position of the declaration in the original source.
+	Position SnapshotPosition `json:"position"`
+
+	// Canonical form of the declaration, printed as Go source.
+	Source string `json:"source"`
+}
+
+// SnapshotPosition is a position in the original source of a Snapshot.
+type SnapshotPosition struct {
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// TakeSnapshot records the canonical form of f. fset is used to record the positions of its
+// declarations.
+func TakeSnapshot(f *ast.File, fset *token.FileSet) (*Snapshot, error) {
+	defer beginComparison("", "")()
+	c := canonicalTree(f)
+
+	s := &Snapshot{
+		Version: SnapshotVersion,
+		Package: c.Name.Name,
+	}
+
+	var imports, others []SnapshotDeclaration
+	for _, d := range collectDeclarations(c) {
+		pos := position(fset, d.decl.Pos())
+		clearPositions(d.decl)
+
+		src, err := printCanonicalDecl(d.decl)
+		if err != nil {
+			return nil, err
+		}
+
+		sd := SnapshotDeclaration{
+			Key: d.key,
+			Position: SnapshotPosition{
+				Filename: pos.Filename,
+				Line:     pos.Line,
+				Column:   pos.Column,
+			},
+			Source: string(src),
+		}
+		if isImportDecl(d.decl) {
+			imports = append(imports, sd)
+		} else {
+			others = append(others, sd)
+		}
+	}
+	s.Declarations = append(imports, others...)
+
+	return s, nil
+}
+
+// TakePackageSnapshot records the canonical form of the package made up of files, as for
+// TakeSnapshot, with the files merged into one. The files must all belong to the same package.
+func TakePackageSnapshot(files []*ast.File, fset *token.FileSet) (*Snapshot, error) {
+	name, err := filesPackageName(files, fset)
+	if err != nil {
+		return nil, err
+	}
+	return TakeSnapshot(mergeFiles(name, sortedFiles(files, fset)), fset)
+}
+
+// WriteSnapshot writes s to w as indented JSON.
+func WriteSnapshot(w io.Writer, s *Snapshot) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var s Snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	if s.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d (want %d)", s.Version, SnapshotVersion)
+	}
+	return &s, nil
+}
+
+// CompareSnapshot compares a snapshot, as the left side, against the live Go package made up of
+// files, as the right side, as for ComparePackageFiles. Since snapshots are recorded in canonical form, the
+// normalizations Canonicalize applies are always enabled in addition to any enabled in opts.
+//
+// Differences are reported at the positions recorded in the snapshot. Positions of declarations are
+// exact, while positions within a declaration refer to its canonical form, so are approximate.
+//
+// opts may be nil. Comparing initialization order is not supported, since snapshots don't record the
+// original order of declarations.
+func CompareSnapshot(s *Snapshot, files []*ast.File, fset *token.FileSet, opts *Options) (*Result, error) {
+	if s == nil || len(files) == 0 {
+		return nil, fmt.Errorf("missing snapshot or package")
+	}
+	if opts != nil && opts.InitOrder {
+		return nil, fmt.Errorf("comparing initialization order is not supported with snapshots")
+	}
+
+	f, snapshotFSet, err := s.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotOpts := Options{}
+	if opts != nil {
+		snapshotOpts = *opts
+	}
+	snapshotOpts.FoldConstants = true
+	snapshotOpts.NormalizeParentheses = true
+	snapshotOpts.FlattenDeclarations = true
+
+	return ComparePackageFiles([]*ast.File{f}, snapshotFSet, files, fset, &snapshotOpts)
+}
+
+// Parse a snapshot back into a syntax tree, with a file set which maps the position of each
+// declaration back to its position in the original source.
+func (s *Snapshot) parse() (*ast.File, *token.FileSet, error) {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "package %s\n", s.Package)
+
+	offsets := make([]int, len(s.Declarations))
+	for i, d := range s.Declarations {
+		builder.WriteString("\n")
+		offsets[i] = builder.Len()
+		builder.WriteString(d.Source)
+		builder.WriteString("\n")
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "snapshot.go", builder.String(), parser.AllErrors)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing snapshot: %v", err)
+	}
+
+	file := fset.File(f.Pos())
+	for i, d := range s.Declarations {
+		if d.Position.Line > 0 {
+			file.AddLineColumnInfo(offsets[i], d.Position.Filename, d.Position.Line, d.Position.Column)
+		}
+	}
+
+	return f, fset, nil
+}
+
+func isImportDecl(d ast.Decl) bool {
+	genDecl, ok := d.(*ast.GenDecl)
+	return ok && genDecl.Tok == token.IMPORT
+}
@@ -0,0 +1,96 @@
+package eqgo
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestCompareSnapshot(t *testing.T) {
+	baseline := `package p
+
+import (
+	"os"
+	"fmt"
+)
+
+var (
+	a, b = 1, 0x2
+)
+
+func F() {
+	fmt.Println(a + b)
+}
+
+func G() int {
+	os.Exit(0)
+	return 1
+}
+`
+
+	testCases := []struct {
+		live    string
+		want    bool
+		wantPos string
+	}{
+		{
+			live: "package p\n\nimport \"fmt\"\nimport \"os\"\n\nfunc G() int { os.Exit(0); return 1 }\nvar a = 1\nvar b = 2\nfunc F() { fmt.Println(a + b) }\n",
+			want: true,
+		},
+		{
+			live:    "package p\n\nimport \"fmt\"\nimport \"os\"\n\nfunc G() int { os.Exit(0); return 2 }\nvar a = 1\nvar b = 2\nfunc F() { fmt.Println(a + b) }\n",
+			want:    false,
+			wantPos: "baseline.go:16:1",
+		},
+	}
+	for _, c := range testCases {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "baseline.go", baseline, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshot, err := TakeSnapshot(f, fset)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := WriteSnapshot(&buf, snapshot); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := ReadSnapshot(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		liveFSet := token.NewFileSet()
+		live, err := parser.ParseFile(liveFSet, "live.go", c.live, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := CompareSnapshot(loaded, []*ast.File{live}, liveFSet, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Equivalent != c.want {
+			t.Errorf("CompareSnapshot(%q) == %t, want %t\n%s", c.live, got.Equivalent, c.want, got.Format(nil))
+		}
+		if c.wantPos != "" && !strings.Contains(got.Format(nil), c.wantPos) {
+			t.Errorf("CompareSnapshot(%q) did not report a difference at %s\n%s", c.live, c.wantPos, got.Format(nil))
+		}
+
+		if _, err := CompareSnapshot(loaded, []*ast.File{live}, liveFSet, &Options{InitOrder: true}); err == nil {
+			t.Errorf("CompareSnapshot(%q) comparing initialization order returned no error", c.live)
+		}
+	}
+}
+
+func TestReadSnapshotVersion(t *testing.T) {
+	if _, err := ReadSnapshot(strings.NewReader(`{"version": 99, "package": "p"}`)); err == nil {
+		t.Errorf("ReadSnapshot() returned no error for an unsupported version")
+	}
+}
@@ -0,0 +1,71 @@
+package eqgo
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+)
+
+// Helpers to compare Go source held in memory, e.g. the output of a generator, without writing it
+// to disk first.
+
+// CompareSources compares the Go packages made up of the source files in a and b, keyed by
+// filename, as for ComparePackageFiles. Every file given is parsed, regardless of its name or build
+// constraints; use loader.LoadFiles to select files as the go command would.
+//
+// Filenames are only used to name the files in positions. Source which fails to parse causes the
+// parser's error to be returned, which lists the positions of the syntax errors found.
+//
+// opts may be nil.
+func CompareSources(a map[string][]byte, b map[string][]byte, opts *Options) (*Result, error) {
+	filesA, fsetA, err := parseSources(a)
+	if err != nil {
+		return nil, err
+	}
+	filesB, fsetB, err := parseSources(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return ComparePackageFiles(filesA, fsetA, filesB, fsetB, opts)
+}
+
+// CompareSource compares the Go source files a and b, as for CompareFiles. In positions, the files
+// are named left.go and right.go. Source which fails to parse causes the parser's error to be
+// returned.
+//
+// opts may be nil.
+func CompareSource(a []byte, b []byte, opts *Options) (*Result, error) {
+	fset := token.NewFileSet()
+	fileA, err := parser.ParseFile(fset, "left.go", a, parser.ParseComments|parser.AllErrors)
+	if err != nil {
+		return nil, err
+	}
+	fileB, err := parser.ParseFile(fset, "right.go", b, parser.ParseComments|parser.AllErrors)
+	if err != nil {
+		return nil, err
+	}
+
+	return CompareFiles(fileA, fset, fileB, fset, opts)
+}
+
+// Parse each of sources, in filename order, into a new file set.
+func parseSources(sources map[string][]byte) ([]*ast.File, *token.FileSet, error) {
+	var filenames []string
+	for filename := range sources {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, filename := range filenames {
+		f, err := parser.ParseFile(fset, filename, sources[filename], parser.ParseComments|parser.AllErrors)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, f)
+	}
+	return files, fset, nil
+}
@@ -0,0 +1,91 @@
+package eqgo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompareSources(t *testing.T) {
+	testCases := []struct {
+		name      string
+		a, b      map[string]string
+		want      bool
+		wantErr   string
+		wantInMsg string
+	}{
+		{
+			name: "equivalent",
+			a:    map[string]string{"a.go": "package p\nfunc F() {}\n", "b.go": "package p\n// G.\nfunc G() {}\n"},
+			b:    map[string]string{"gen.go": "package p\nfunc G() {}\nfunc F() {\n}\n"},
+			want: true,
+		},
+		{
+			name:      "not equivalent",
+			a:         map[string]string{"a.go": "package p\nfunc F() {}\n"},
+			b:         map[string]string{"gen.go": "package p\nfunc F() { println() }\n"},
+			want:      false,
+			wantInMsg: "gen.go:2:",
+		},
+		{
+			name:    "syntax error",
+			a:       map[string]string{"a.go": "package p\n"},
+			b:       map[string]string{"gen.go": "package p\nfunc F() {\n"},
+			wantErr: "gen.go:2:",
+		},
+		{
+			name:    "mixed packages",
+			a:       map[string]string{"a.go": "package p\n", "b.go": "package q\n"},
+			b:       map[string]string{"gen.go": "package p\n"},
+			wantErr: "found package q",
+		},
+		{
+			name:    "no files",
+			a:       map[string]string{},
+			b:       map[string]string{"gen.go": "package p\n"},
+			wantErr: "missing package",
+		},
+	}
+
+	toBytes := func(m map[string]string) map[string][]byte {
+		b := make(map[string][]byte)
+		for name, src := range m {
+			b[name] = []byte(src)
+		}
+		return b
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := CompareSources(toBytes(tc.a), toBytes(tc.b), nil)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Equivalent != tc.want {
+				t.Errorf("Equivalent = %t, want %t\n%s", r.Equivalent, tc.want, r.Format(nil))
+			}
+			if msg := r.Format(nil); !strings.Contains(msg, tc.wantInMsg) {
+				t.Errorf("message does not mention %q:\n%s", tc.wantInMsg, msg)
+			}
+		})
+	}
+}
+
+func TestCompareSource(t *testing.T) {
+	r, err := CompareSource([]byte("package p\nconst N = 0x10\n"), []byte("package p\n\nconst N = 16\n"), &Options{FoldConstants: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Equivalent {
+		t.Errorf("sources not equivalent:\n%s", r.Format(nil))
+	}
+
+	if _, err := CompareSource([]byte("package p\n"), []byte("package p\nvar\n"), nil); err == nil || !strings.Contains(err.Error(), "right.go") {
+		t.Errorf("error = %v, want a syntax error in right.go", err)
+	}
+}
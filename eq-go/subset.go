@@ -0,0 +1,92 @@
+package eqgo
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// Helpers to check that every declaration of one file has an equivalent declaration in another,
+// while allowing the other file to declare more.
+
+// Compare a to b in subset mode. Returns the comparison result, a tree describing any declarations
+// of a which have no equivalent declaration in b, and the declarations which only exist in b, i.e.
+// which don't share a name with any declaration of a.
+func compareSubset(a *ast.File, b *ast.File, fsetB *token.FileSet) (int, *node, []DeclarationResult) {
+	sortDeclarations(a)
+	sortDeclarations(b)
+	declsA, declsB := collectDeclarations(a), collectDeclarations(b)
+
+	// Declarations with the same name (e.g. several init functions) are keyed "init", "init#2",
+	// etc., so may be listed in a different order on each side. Match them by their base key, with
+	// the names of the packages being compared treated as equivalent.
+	candidates := make(map[string][]*declaration)
+	for i := range declsB {
+		k := normalizePackageName(baseDeclarationKey(declsB[i].key))
+		candidates[k] = append(candidates[k], &declsB[i])
+	}
+	matched := make(map[*declaration]bool)
+
+	// First pair up equivalent declarations, then compare each remaining left declaration with the
+	// first remaining right declaration of the same name, if any, to describe how they differ.
+	found := make([]bool, len(declsA))
+	for i, left := range declsA {
+		for _, right := range candidates[normalizePackageName(baseDeclarationKey(left.key))] {
+			if matched[right] {
+				continue
+			}
+			if cmp, _ := compareDecls(left.decl, right.decl); cmp == 0 {
+				matched[right] = true
+				found[i] = true
+				break
+			}
+		}
+	}
+
+	retCmp := 0
+	var children []*node
+	for i, left := range declsA {
+		if found[i] {
+			continue
+		}
+
+		var right *declaration
+		for _, candidate := range candidates[normalizePackageName(baseDeclarationKey(left.key))] {
+			if !matched[candidate] {
+				right = candidate
+				break
+			}
+		}
+
+		if right == nil {
+			setIfUnset(&retCmp, -1)
+			children = append(children, newNode("declaration missing from right: "+left.key, left.decl, nil, nil))
+			continue
+		}
+
+		matched[right] = true
+		cmp, child := compareDecls(left.decl, right.decl)
+		setIfUnset(&retCmp, cmp)
+		children = append(children, newNode("declarations did not match: "+left.key, left.decl, right.decl, &[]*node{child}))
+	}
+
+	var additions []DeclarationResult
+	for i := range declsB {
+		if !matched[&declsB[i]] {
+			additions = append(additions, DeclarationResult{
+				Key:      declsB[i].key,
+				RightPos: position(fsetB, declsB[i].decl.Pos()),
+			})
+		}
+	}
+
+	cmp, root := newRetVal(retCmp, "left is not a subset of right", nil, nil, children)
+	return cmp, root, additions
+}
+
+func baseDeclarationKey(key string) string {
+	if i := strings.LastIndex(key, "#"); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
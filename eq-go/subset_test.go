@@ -0,0 +1,78 @@
+package eqgo
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestSubsetComparison(t *testing.T) {
+	testCases := []struct {
+		a             string
+		b             string
+		want          bool
+		wantAdditions []string
+	}{
+		{
+			a:    "func F() {}; type T int",
+			b:    "type T int; func F() {}",
+			want: true,
+		},
+		{
+			a:             "func F() {}",
+			b:             "import \"fmt\"; func F() {}; func (T) Helper() { fmt.Println() }; type T int",
+			want:          true,
+			wantAdditions: []string{"import \"fmt\"", "method T.Helper", "type T"},
+		},
+		{
+			a:    "func F() {}; func G() {}",
+			b:    "func F() {}",
+			want: false,
+		},
+		{
+			a:             "func F() int { return 1 }",
+			b:             "func F() int { return 2 }; func G() {}",
+			want:          false,
+			wantAdditions: []string{"func G"},
+		},
+		{
+			a:             "func init() { a() }; func init() { b() }",
+			b:             "func init() { c() }; func init() { b() }; func init() { a() }",
+			want:          true,
+			wantAdditions: []string{"func init"},
+		},
+		{
+			a:             "var x = []int{1, 2}",
+			b:             "var x = []int{2, 1}; func F() {}",
+			want:          true,
+			wantAdditions: []string{"func F"},
+		},
+	}
+	for _, c := range testCases {
+		fset := token.NewFileSet()
+		fileA, err := parser.ParseFile(fset, "a.go", "package p; "+c.a, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileB, err := parser.ParseFile(fset, "b.go", "package p; "+c.b, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := CompareFiles(fileA, fset, fileB, fset, &Options{Subset: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Equivalent != c.want {
+			t.Errorf("CompareFiles(%q, %q) == %t, want %t\n%s", c.a, c.b, got.Equivalent, c.want, got.Format(nil))
+		}
+
+		var additions []string
+		for _, d := range got.Additions {
+			additions = append(additions, baseDeclarationKey(d.Key))
+		}
+		if !equalStringSlices(additions, c.wantAdditions) {
+			t.Errorf("CompareFiles(%q, %q).Additions == %v, want %v", c.a, c.b, additions, c.wantAdditions)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package eqgo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"sort"
+
+	"github.com/kevinmbeaulieu/eq-go/eq-go/loader"
+)
+
+// Helpers to compare packages together with their tests.
+
+// CompareLoadedPackages compares two directories' packages as loaded by loader, as for
+// ComparePackageFiles with opts.
+//
+// If opts.IncludeTests is set, in-package test files are compared as part of the library package
+// and the external test packages are compared separately, with the result in the ExternalTest
+// field of the returned Result. Test functions are matched by name like any other function, and
+// the output comments of examples are compared too, since `go test` checks them.
+//
+// opts may be nil.
+func CompareLoadedPackages(a *loader.Packages, b *loader.Packages, opts *Options) (*Result, error) {
+	if a == nil || b == nil {
+		return nil, fmt.Errorf("missing package")
+	}
+
+	includeTests := opts != nil && opts.IncludeTests
+	if !includeTests {
+		if len(a.Files) == 0 || len(b.Files) == 0 {
+			return nil, fmt.Errorf("missing package")
+		}
+		return comparePackageFiles(a.Name, a.Files, a.Fset, b.Name, b.Files, b.Fset, opts, true)
+	}
+
+	r, err := compareWithExamples(a.Name, withTestFiles(a), a.Fset, b.Name, withTestFiles(b), b.Fset, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(a.ExternalTestFiles) > 0 || len(b.ExternalTestFiles) > 0 {
+		r.ExternalTest, err = compareWithExamples(a.Name+"_test", a.ExternalTestFiles, a.Fset, b.Name+"_test", b.ExternalTestFiles, b.Fset, opts)
+		if err != nil {
+			return nil, fmt.Errorf("comparing external test packages: %v", err)
+		}
+		if !r.ExternalTest.Equivalent {
+			r.Equivalent = false
+		}
+	}
+
+	return r, nil
+}
+
+// The files of the library package together with its in-package test files, sorted by filename.
+func withTestFiles(p *loader.Packages) []*ast.File {
+	files := append(append([]*ast.File{}, p.Files...), p.TestFiles...)
+	return sortedFiles(files, p.Fset)
+}
+
+// Compare two packages as for ComparePackageFiles, and also compare the output comments of the
+// examples they have in common. The files of each package must be sorted by filename.
+func compareWithExamples(nameA string, a []*ast.File, fsetA *token.FileSet, nameB string, b []*ast.File, fsetB *token.FileSet, opts *Options) (*Result, error) {
+	// Examples must be found before the files are merged and normalized, which drops comments.
+	examplesA := doc.Examples(a...)
+	examplesB := doc.Examples(b...)
+
+	r, err := comparePackageFiles(nameA, a, fsetA, nameB, b, fsetB, opts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Outputs are compared exactly, without treating the packages' names as equivalent.
+	endComparison := beginComparison("", "")
+	cmp, n := compareExampleOutputs(examplesA, examplesB)
+	endComparison()
+	if cmp != 0 {
+		r.addDifference("packages did not match", n)
+	}
+	return r, nil
+}
+
+// Compare the expected output of each example which exists on both sides. Examples which only
+// exist on one side are reported by the comparison of the example functions themselves.
+func compareExampleOutputs(a []*doc.Example, b []*doc.Example) (int, *node) {
+	examplesB := make(map[string]*doc.Example)
+	for _, e := range b {
+		examplesB[e.Name] = e
+	}
+
+	sort.Slice(a, func(i, j int) bool {
+		return a[i].Name < a[j].Name
+	})
+
+	retCmp := 0
+	var children []*node
+	for _, exampleA := range a {
+		exampleB, ok := examplesB[exampleA.Name]
+		if !ok {
+			continue
+		}
+
+		describe := func(e *doc.Example) string {
+			switch {
+			case e.Unordered:
+				return fmt.Sprintf("unordered output %q", e.Output)
+			case e.Output != "" || e.EmptyOutput:
+				return fmt.Sprintf("output %q", e.Output)
+			}
+			return "no output"
+		}
+
+		if cmp, child := compareStrings(describe(exampleA), describe(exampleB)); cmp != 0 {
+			setIfUnset(&retCmp, cmp)
+			children = append(children, newNode("example outputs did not match: Example"+exampleA.Name, exampleA.Code, exampleB.Code, &[]*node{child}))
+		}
+	}
+
+	return newRetVal(retCmp, "example outputs did not match", nil, nil, children)
+}
@@ -0,0 +1,121 @@
+package eqgo
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kevinmbeaulieu/eq-go/eq-go/loader"
+)
+
+func TestCompareLoadedPackages(t *testing.T) {
+	const lib = "package foo\nfunc F() int { return 1 }\n"
+
+	testCases := []struct {
+		name         string
+		left, right  map[string]string
+		includeTests bool
+		want         bool
+		wantXTest    bool
+	}{
+		{
+			name:         "tests ignored by default",
+			left:         map[string]string{"foo.go": lib, "foo_test.go": "package foo\nfunc TestF() {}\n"},
+			right:        map[string]string{"foo.go": lib, "foo_test.go": "package foo\nfunc TestG() {}\n"},
+			includeTests: false,
+			want:         true,
+		},
+		{
+			name:         "in-package tests overlay library",
+			left:         map[string]string{"foo.go": lib, "foo_test.go": "package foo\nfunc TestF() {}\n"},
+			right:        map[string]string{"foo.go": lib + "func TestF() {}\n"},
+			includeTests: true,
+			want:         true,
+		},
+		{
+			name:         "test functions matched by name",
+			left:         map[string]string{"foo.go": lib, "foo_test.go": "package foo\nfunc TestB() {}\nfunc TestA() { F() }\n"},
+			right:        map[string]string{"foo.go": lib, "a_test.go": "package foo\nfunc TestA() { F() }\n", "b_test.go": "package foo\nfunc TestB() {}\n"},
+			includeTests: true,
+			want:         true,
+		},
+		{
+			name:         "different test bodies",
+			left:         map[string]string{"foo.go": lib, "foo_test.go": "package foo\nfunc TestF() { F() }\n"},
+			right:        map[string]string{"foo.go": lib, "foo_test.go": "package foo\nfunc TestF() {}\n"},
+			includeTests: true,
+			want:         false,
+		},
+		{
+			name:         "equivalent external tests",
+			left:         map[string]string{"foo.go": lib, "x_test.go": "package foo_test\nimport \"testing\"\nfunc TestF(t *testing.T) {}\n"},
+			right:        map[string]string{"foo.go": lib, "y_test.go": "package foo_test\nimport \"testing\"\nfunc TestF(t *testing.T) {}\n"},
+			includeTests: true,
+			want:         true,
+			wantXTest:    true,
+		},
+		{
+			name:         "external test only on one side",
+			left:         map[string]string{"foo.go": lib, "x_test.go": "package foo_test\nfunc TestF() {}\n"},
+			right:        map[string]string{"foo.go": lib},
+			includeTests: true,
+			want:         false,
+			wantXTest:    false,
+		},
+		{
+			name:         "example outputs differ",
+			left:         map[string]string{"foo.go": lib, "foo_test.go": "package foo\nfunc ExampleF() {\n\tprintln(F())\n\t// Output: 1\n}\n"},
+			right:        map[string]string{"foo.go": lib, "foo_test.go": "package foo\nfunc ExampleF() {\n\tprintln(F())\n\t// Output: 2\n}\n"},
+			includeTests: true,
+			want:         false,
+		},
+		{
+			name:         "example output ordering differs",
+			left:         map[string]string{"foo.go": lib, "foo_test.go": "package foo\nfunc ExampleF() {\n\tprintln(F())\n\t// Output: 1\n}\n"},
+			right:        map[string]string{"foo.go": lib, "foo_test.go": "package foo\nfunc ExampleF() {\n\tprintln(F())\n\t// Unordered output: 1\n}\n"},
+			includeTests: true,
+			want:         false,
+		},
+		{
+			name:         "other comments in examples ignored",
+			left:         map[string]string{"foo.go": lib, "foo_test.go": "package foo\nfunc ExampleF() {\n\t// Print it.\n\tprintln(F())\n\t// Output: 1\n}\n"},
+			right:        map[string]string{"foo.go": lib, "foo_test.go": "package foo\nfunc ExampleF() {\n\tprintln(F())\n\t// Output:\n\t// 1\n}\n"},
+			includeTests: true,
+			want:         true,
+		},
+		{
+			name:         "example outputs ignored without tests",
+			left:         map[string]string{"foo.go": lib + "func ExampleF() {\n\t// Output: 1\n}\n"},
+			right:        map[string]string{"foo.go": lib + "func ExampleF() {\n\t// Output: 2\n}\n"},
+			includeTests: false,
+			want:         true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			leftDir, rightDir := writeTree(t, tc.left), writeTree(t, tc.right)
+			defer os.RemoveAll(leftDir)
+			defer os.RemoveAll(rightDir)
+
+			a, err := loader.Load(leftDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := loader.Load(rightDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := CompareLoadedPackages(a, b, &Options{IncludeTests: tc.includeTests})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Equivalent != tc.want {
+				t.Errorf("Equivalent = %t, want %t\n%s", r.Equivalent, tc.want, r.Format(nil))
+			}
+			if r.ExternalTest != nil && r.ExternalTest.Equivalent != tc.wantXTest {
+				t.Errorf("ExternalTest.Equivalent = %t, want %t", r.ExternalTest.Equivalent, tc.wantXTest)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package eqgo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// DeclarationChange classifies how a declaration changed between a base package and two
+// descendants of it.
+type DeclarationChange int
+
+const (
+	// The declaration is equivalent in all three packages.
+	Unchanged DeclarationChange = iota
+
+	// The declaration differs from the base only in the left package.
+	ChangedLeft
+
+	// The declaration differs from the base only in the right package.
+	ChangedRight
+
+	// The declaration differs from the base in both descendants, but they are equivalent to each
+	// other.
+	ChangedBoth
+
+	// The declaration differs from the base in both descendants, and they differ from each other.
+	Conflict
+)
+
+func (c DeclarationChange) String() string {
+	switch c {
+	case Unchanged:
+		return "unchanged"
+	case ChangedLeft:
+		return "changed on left"
+	case ChangedRight:
+		return "changed on right"
+	case ChangedBoth:
+		return "changed identically on both sides"
+	case Conflict:
+		return "conflict"
+	}
+	return fmt.Sprintf("DeclarationChange(%d)", int(c))
+}
+
+// ThreeWayDeclaration describes how a single top-level declaration changed between a base package
+// and two descendants of it.
+type ThreeWayDeclaration struct {
+	// Identifies the declaration within its package, as in DeclarationResult.
+	Key string
+
+	// Position of the declaration in each package. A position is invalid if the declaration does
+	// not exist in that package. Adding or removing a declaration counts as changing it.
+	BasePos, LeftPos, RightPos token.Position
+
+	Change DeclarationChange
+}
+
+// ThreeWayResult describes the outcome of comparing a base package against two descendants.
+type ThreeWayResult struct {
+	// Every top-level declaration found in any of the packages, sorted by key.
+	Declarations []ThreeWayDeclaration
+
+	// Whether any declaration conflicts.
+	Conflict bool
+}
+
+// CompareThreeWay compares the base package made up of the files in base against two packages
+// descended from it, made up of the files in left and right, and classifies each top-level
+// declaration as unchanged, changed on one side only, changed identically on both sides, or
+// conflicting. Declarations are compared using the same notion of equivalence as
+// ComparePackageFiles with opts, so reordering and formatting never count as changes.
+//
+// opts may be nil. Subset mode is not supported, and neither is comparing initialization order,
+// including tests or reporting layout changes.
+func CompareThreeWay(base []*ast.File, fsetBase *token.FileSet, left []*ast.File, fsetLeft *token.FileSet, right []*ast.File, fsetRight *token.FileSet, opts *Options) (*ThreeWayResult, error) {
+	pkgs := [][]*ast.File{base, left, right}
+	fsets := []*token.FileSet{fsetBase, fsetLeft, fsetRight}
+
+	names := make([]string, len(pkgs))
+	for i, pkg := range pkgs {
+		var err error
+		if names[i], err = filesPackageName(pkg, fsets[i]); err != nil {
+			return nil, err
+		}
+	}
+	if opts != nil && opts.Subset {
+		return nil, fmt.Errorf("subset mode is not supported in three-way comparisons")
+	}
+	if opts != nil && opts.InitOrder {
+		return nil, fmt.Errorf("comparing initialization order is not supported in three-way comparisons")
+	}
+	if opts != nil && opts.IncludeTests {
+		return nil, fmt.Errorf("comparing tests is not supported in three-way comparisons")
+	}
+	if opts != nil && opts.ReportLayout {
+		return nil, fmt.Errorf("reporting layout changes is not supported in three-way comparisons")
+	}
+
+	defer beginComparison("", "")()
+
+	if opts != nil && opts.TypeCheck {
+		if err := startTypeCheckedComparisonOfPackages(names, pkgs, fsets); err != nil {
+			return nil, err
+		}
+		defer endTypeCheckedComparison()
+	}
+
+	groups := make([]map[string][]*declaration, len(pkgs))
+	for i, pkg := range pkgs {
+		f := cloneForComparison(mergeFiles(names[i], sortedFiles(pkg, fsets[i]))).(*ast.File)
+		normalizeFile(f, opts)
+		sortDeclarations(f)
+		groups[i] = groupDeclarations(collectDeclarations(f))
+	}
+
+	// Pair up the declarations of packages i and j with the same name, with the names of the two
+	// packages treated as equivalent, as in ComparePackageFiles.
+	pair := func(i int, j int, a []*declaration, b []*declaration) [][2]*declaration {
+		equivalentPackageNameA = names[i]
+		equivalentPackageNameB = names[j]
+		return pairEquivalentDeclarations(a, b)
+	}
+
+	// Whether declarations a and b of packages i and j are equivalent.
+	equivalent := func(i int, j int, a *declaration, b *declaration) bool {
+		if a == nil || b == nil {
+			return a == nil && b == nil
+		}
+		equivalentPackageNameA = names[i]
+		equivalentPackageNameB = names[j]
+		cmp, _ := compareDecls(a.decl, b.decl)
+		return cmp == 0
+	}
+
+	pos := func(i int, d *declaration) token.Position {
+		if d != nil {
+			return position(fsets[i], d.decl.Pos())
+		}
+		return token.Position{}
+	}
+
+	r := &ThreeWayResult{}
+	for _, k := range groupKeys(groups...) {
+		key := groupDisplayKey(k, groups...)
+
+		// Match each base declaration with its counterparts on each side, then match the remaining
+		// declarations added on each side with each other. Declarations with the same name (e.g.
+		// several init functions) are matched by equivalence, so reordering them is not a change.
+		var triples [][3]*declaration
+		leftOf := make(map[*declaration]*declaration)
+		var leftAdded []*declaration
+		for _, p := range pair(0, 1, groups[0][k], groups[1][k]) {
+			if p[0] == nil {
+				leftAdded = append(leftAdded, p[1])
+			} else {
+				leftOf[p[0]] = p[1]
+			}
+		}
+		var rightAdded []*declaration
+		for _, p := range pair(0, 2, groups[0][k], groups[2][k]) {
+			if p[0] == nil {
+				rightAdded = append(rightAdded, p[1])
+			} else {
+				triples = append(triples, [3]*declaration{p[0], leftOf[p[0]], p[1]})
+			}
+		}
+		for _, p := range pair(1, 2, leftAdded, rightAdded) {
+			triples = append(triples, [3]*declaration{nil, p[0], p[1]})
+		}
+
+		for i, t := range triples {
+			d := ThreeWayDeclaration{
+				Key:      numberedDeclarationKey(key, i),
+				BasePos:  pos(0, t[0]),
+				LeftPos:  pos(1, t[1]),
+				RightPos: pos(2, t[2]),
+			}
+
+			leftUnchanged, rightUnchanged := equivalent(0, 1, t[0], t[1]), equivalent(0, 2, t[0], t[2])
+			switch {
+			case leftUnchanged && rightUnchanged:
+				d.Change = Unchanged
+			case rightUnchanged:
+				d.Change = ChangedLeft
+			case leftUnchanged:
+				d.Change = ChangedRight
+			case equivalent(1, 2, t[1], t[2]):
+				d.Change = ChangedBoth
+			default:
+				d.Change = Conflict
+				r.Conflict = true
+			}
+
+			r.Declarations = append(r.Declarations, d)
+		}
+	}
+
+	return r, nil
+}
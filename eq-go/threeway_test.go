@@ -0,0 +1,132 @@
+package eqgo
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func parseThreeWayTestPackage(t *testing.T, src string) ([]*ast.File, *token.FileSet) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", "package p\n"+src, parser.AllErrors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []*ast.File{f}, fset
+}
+
+func TestCompareThreeWay(t *testing.T) {
+	base, fsetBase := parseThreeWayTestPackage(t, `
+func A() int { return 1 }
+func B() int { return 1 }
+func C() int { return 1 }
+func D() int { return 1 }
+func E() int { return 1 }
+func Removed() {}
+`)
+	left, fsetLeft := parseThreeWayTestPackage(t, `
+func E() int { return 2 }
+func D() int { return 2 }
+func C() int { return 1 }
+func B() int { return 2 }
+func A() int {
+	return 1
+}
+func Added() {}
+`)
+	right, fsetRight := parseThreeWayTestPackage(t, `
+func A() int { return 1 }
+func B() int { return 1 }
+func C() int { return 2 }
+func D() int { return 2 }
+func E() int { return 3 }
+`)
+
+	got, err := CompareThreeWay(base, fsetBase, left, fsetLeft, right, fsetRight, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]DeclarationChange{
+		"func A":       Unchanged,
+		"func Added":   ChangedLeft,
+		"func B":       ChangedLeft,
+		"func C":       ChangedRight,
+		"func D":       ChangedBoth,
+		"func E":       Conflict,
+		"func Removed": ChangedBoth,
+	}
+	if len(got.Declarations) != len(want) {
+		t.Fatalf("CompareThreeWay() returned %d declarations, want %d", len(got.Declarations), len(want))
+	}
+	for _, d := range got.Declarations {
+		if d.Change != want[d.Key] {
+			t.Errorf("CompareThreeWay() classified %s as %v, want %v", d.Key, d.Change, want[d.Key])
+		}
+	}
+	if !got.Conflict {
+		t.Errorf("CompareThreeWay().Conflict == false, want true")
+	}
+
+	for _, opts := range []*Options{{Subset: true}, {InitOrder: true}, {IncludeTests: true}, {ReportLayout: true}} {
+		if _, err := CompareThreeWay(base, fsetBase, left, fsetLeft, right, fsetRight, opts); err == nil {
+			t.Errorf("CompareThreeWay() with options %+v returned no error", *opts)
+		}
+	}
+}
+
+func TestCompareThreeWayReordering(t *testing.T) {
+	testCases := []struct {
+		name              string
+		base, left, right string
+		want              map[string]DeclarationChange
+	}{
+		{
+			name:  "reordered composite literal",
+			base:  "var x = []int{1, 2}",
+			left:  "var x = []int{2, 1}",
+			right: "var x = []int{1, 2}",
+			want:  map[string]DeclarationChange{"var x": Unchanged},
+		},
+		{
+			name:  "reordered init functions",
+			base:  "func init() { a() }\nfunc init() { b() }",
+			left:  "func init() { b() }\nfunc init() { a() }",
+			right: "func init() { a() }\nfunc init() { b() }",
+			want:  map[string]DeclarationChange{"func init": Unchanged, "func init#2": Unchanged},
+		},
+		{
+			name:  "reordered init functions with one changed",
+			base:  "func init() { a() }\nfunc init() { b() }",
+			left:  "func init() { b() }\nfunc init() { c() }",
+			right: "func init() { b() }\nfunc init() { a() }",
+			want:  map[string]DeclarationChange{"func init": ChangedLeft, "func init#2": Unchanged},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			base, fsetBase := parseThreeWayTestPackage(t, tc.base)
+			left, fsetLeft := parseThreeWayTestPackage(t, tc.left)
+			right, fsetRight := parseThreeWayTestPackage(t, tc.right)
+
+			got, err := CompareThreeWay(base, fsetBase, left, fsetLeft, right, fsetRight, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got.Declarations) != len(tc.want) {
+				t.Fatalf("CompareThreeWay() returned %d declarations, want %d", len(got.Declarations), len(tc.want))
+			}
+			for _, d := range got.Declarations {
+				if d.Change != tc.want[d.Key] {
+					t.Errorf("CompareThreeWay() classified %s as %v, want %v", d.Key, d.Change, tc.want[d.Key])
+				}
+			}
+			if got.Conflict {
+				t.Errorf("CompareThreeWay().Conflict == true, want false")
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package eqgo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/importer"
+	"go/token"
+	"go/types"
+)
+
+// Helpers to type-check packages with go/types so that comparisons can take resolved objects,
+// expression types and constant values into account.
+//
+// Imports are resolved by type-checking the imported packages from source, using the local GOROOT
+// and module cache, so no network access or compiled export data is required.
+
+type typeCheckedPackage struct {
+	pkg  *types.Package
+	info *types.Info
+}
+
+// Type information for every side of the comparison in progress, if comparing in type-checked
+// mode. Like the rest of the comparison's state, it is guarded by comparisonMu.
+var typeCheckedPackages []*typeCheckedPackage
+
+// Originals of the nodes copied by cloneForComparison during the comparison in progress, so that
+// type information can be looked up for the copies. Guarded by comparisonMu.
+var clonedNodes map[ast.Node]ast.Node
+
+// Create an importer for a single comparison, to be shared by all sides of it so that each imported
+// package is only loaded once. Importers are not shared between comparisons, so that imported
+// packages aren't kept in memory once a comparison is done, and so that comparisons don't need to
+// synchronize their use of the importer.
+func newImporter() types.Importer {
+	return importer.ForCompiler(token.NewFileSet(), "source", nil)
+}
+
+func typeCheck(path string, files []*ast.File, fset *token.FileSet, imp types.Importer) (*typeCheckedPackage, error) {
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+
+	var errs []error
+	conf := types.Config{
+		Importer: imp,
+		Error: func(err error) {
+			errs = append(errs, err)
+		},
+	}
+
+	pkg, _ := conf.Check(path, fset, files, info)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("type-checking %s: %v", path, errs[0])
+	}
+	return &typeCheckedPackage{pkg: pkg, info: info}, nil
+}
+
+// Type-check both sides of a comparison and make their type information available to the compare*
+// functions until endTypeCheckedComparison is called. The comparison must have been started with
+// beginComparison.
+func startTypeCheckedComparison(pathA string, filesA []*ast.File, fsetA *token.FileSet, pathB string, filesB []*ast.File, fsetB *token.FileSet) error {
+	imp := newImporter()
+	a, err := typeCheck(pathA, filesA, fsetA, imp)
+	if err != nil {
+		return err
+	}
+	b, err := typeCheck(pathB, filesB, fsetB, imp)
+	if err != nil {
+		return err
+	}
+
+	typeCheckedPackages = []*typeCheckedPackage{a, b}
+	return nil
+}
+
+// Type-check any number of packages being compared with each other, as for
+// startTypeCheckedComparison.
+func startTypeCheckedComparisonOfPackages(names []string, pkgs [][]*ast.File, fsets []*token.FileSet) error {
+	imp := newImporter()
+	var checked []*typeCheckedPackage
+	for i, files := range pkgs {
+		c, err := typeCheck(names[i], files, fsets[i], imp)
+		if err != nil {
+			return err
+		}
+		checked = append(checked, c)
+	}
+
+	typeCheckedPackages = checked
+	return nil
+}
+
+func endTypeCheckedComparison() {
+	typeCheckedPackages = nil
+}
+
+// Whether a comparison is in progress in type-checked mode.
+func typeChecked() bool {
+	return len(typeCheckedPackages) > 0
+}
+
+func isTypeCheckedPackage(pkg *types.Package) bool {
+	for _, p := range typeCheckedPackages {
+		if p.pkg == pkg {
+			return true
+		}
+	}
+	return false
+}
+
+// Object which an identifier declares or refers to, or nil if unknown.
+func objectOf(ident *ast.Ident) types.Object {
+	ident = originalNode(ident).(*ast.Ident)
+	for _, p := range typeCheckedPackages {
+		if obj := p.info.Defs[ident]; obj != nil {
+			return obj
+		}
+		if obj := p.info.Uses[ident]; obj != nil {
+			return obj
+		}
+	}
+	return nil
+}
+
+// Package name object which an import spec declares, or nil if unknown.
+func importedPackageOf(spec *ast.ImportSpec) *types.PkgName {
+	spec = originalNode(spec).(*ast.ImportSpec)
+	for _, p := range typeCheckedPackages {
+		if spec.Name != nil {
+			if obj, ok := p.info.Defs[spec.Name].(*types.PkgName); ok {
+				return obj
+			}
+		}
+		if obj, ok := p.info.Implicits[spec].(*types.PkgName); ok {
+			return obj
+		}
+	}
+	return nil
+}
+
+func typeAndValueOf(x ast.Expr) (types.TypeAndValue, bool) {
+	x = originalNode(x).(ast.Expr)
+	for _, p := range typeCheckedPackages {
+		if tv, ok := p.info.Types[x]; ok && tv.Type != nil {
+			return tv, true
+		}
+	}
+	return types.TypeAndValue{}, false
+}
+
+// Qualify names from the packages being compared relative to their own package, so that types
+// declared by either side print the same, and names from any other package by import path.
+func relativeQualifier(pkg *types.Package) string {
+	if isTypeCheckedPackage(pkg) {
+		return ""
+	}
+	return pkg.Path()
+}
+
+func typeString(t types.Type) string {
+	return types.TypeString(t, relativeQualifier)
+}
+
+// Describe what an object is independently of which side of the comparison it comes from, e.g. the
+// same imported package is described the same way regardless of the name it was imported under.
+func objectDescription(obj types.Object) string {
+	if pkgName, ok := obj.(*types.PkgName); ok {
+		return "package " + pkgName.Imported().Path()
+	}
+
+	if obj.Pkg() == nil {
+		return "universe " + obj.Name()
+	}
+
+	owner := obj.Pkg().Path()
+	if isTypeCheckedPackage(obj.Pkg()) {
+		owner = ""
+	}
+
+	switch {
+	case obj.Parent() == obj.Pkg().Scope():
+		return fmt.Sprintf("package-level %s.%s", owner, obj.Name())
+	case obj.Parent() == nil:
+		// Struct fields, methods and interface methods.
+		return fmt.Sprintf("member %s.%s", owner, obj.Name())
+	default:
+		return "local " + obj.Name()
+	}
+}
+
+// Compare two identifiers by the objects they resolve to. ok is false if either identifier could
+// not be resolved.
+func compareResolvedIdentifiers(a *ast.Ident, b *ast.Ident) (cmp int, n *node, ok bool) {
+	objA, objB := objectOf(a), objectOf(b)
+	if objA == nil || objB == nil {
+		return 0, nil, false
+	}
+
+	cmp, child := compareStrings(objectDescription(objA), objectDescription(objB))
+	return cmp, newNode("resolved objects did not match", a, b, &[]*node{child}), true
+}
+
+// Compare two expressions by their types and, for constant expressions, their values. ok is false
+// if the comparison is inconclusive and the expressions need to be compared structurally.
+func compareTypedExpressions(a ast.Expr, b ast.Expr) (cmp int, n *node, ok bool) {
+	tvA, okA := typeAndValueOf(a)
+	tvB, okB := typeAndValueOf(b)
+	if !okA || !okB {
+		return 0, nil, false
+	}
+
+	if cmp, child := compareStrings(typeString(tvA.Type), typeString(tvB.Type)); cmp != 0 {
+		return cmp, newNode("expression types did not match", a, b, &[]*node{child}), true
+	}
+
+	if tvA.Value == nil || tvB.Value == nil {
+		return 0, nil, false
+	}
+
+	if constant.Compare(tvA.Value, token.EQL, tvB.Value) {
+		return 0, nil, true
+	}
+	cmp, child := compareStrings(tvA.Value.ExactString(), tvB.Value.ExactString())
+	if cmp == 0 {
+		// Values of different kinds which print the same.
+		cmp, child = compareInts(int(tvA.Value.Kind()), int(tvB.Value.Kind()))
+	}
+	return cmp, newNode("constant values did not match", a, b, &[]*node{child}), true
+}
+
+// Whether two import specs import the same package under names which are interchangeable, i.e.
+// neither is a blank or dot import.
+func importNamesEquivalent(a *ast.ImportSpec, b *ast.ImportSpec) bool {
+	special := func(s *ast.ImportSpec) bool {
+		return s.Name != nil && (s.Name.Name == "_" || s.Name.Name == ".")
+	}
+	if special(a) || special(b) {
+		return false
+	}
+
+	pkgA, pkgB := importedPackageOf(a), importedPackageOf(b)
+	return pkgA != nil && pkgB != nil && pkgA.Imported().Path() == pkgB.Imported().Path()
+}
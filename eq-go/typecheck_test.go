@@ -0,0 +1,139 @@
+package eqgo
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"sync"
+	"testing"
+)
+
+func TestTypeCheckedComparison(t *testing.T) {
+	testCases := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{
+			a:    "import str \"strings\"; var X = str.ToUpper(\"x\")",
+			b:    "import \"strings\"; var X = strings.ToUpper(\"x\")",
+			want: true,
+		},
+		{
+			a:    "import _ \"strings\"",
+			b:    "import \"strings\"; var _ = strings.ToUpper",
+			want: false,
+		},
+		{
+			a:    "const N = 4; var X = N * 2",
+			b:    "const N = 4; var X = 8",
+			want: true,
+		},
+		{
+			a:    "const N = 4; var X = N * 2",
+			b:    "const N = 4; var X = 9",
+			want: false,
+		},
+		{
+			a:    "var X int64 = 1 << 3",
+			b:    "var X int64 = 0x8",
+			want: true,
+		},
+		{
+			a:    "func f() bool { return true }",
+			b:    "func f() bool { true := false; return true }",
+			want: false,
+		},
+		{
+			a:    "func f() int { var x int32; return int(x) }",
+			b:    "func f() int { var x int64; return int(x) }",
+			want: false,
+		},
+	}
+	for _, c := range testCases {
+		fset := token.NewFileSet()
+		fileA, err := parser.ParseFile(fset, "a.go", "package p; "+c.a, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileB, err := parser.ParseFile(fset, "b.go", "package p; "+c.b, parser.AllErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := CompareFiles(fileA, fset, fileB, fset, &Options{TypeCheck: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Equivalent != c.want {
+			t.Errorf("CompareFiles(%q, %q) == %t, want %t\n%s", c.a, c.b, got.Equivalent, c.want, got.Format(nil))
+		}
+	}
+}
+
+func TestTypeCheckedComparisonErrors(t *testing.T) {
+	fset := token.NewFileSet()
+	fileA, err := parser.ParseFile(fset, "a.go", "package p; var X int = \"x\"", parser.AllErrors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileB, err := parser.ParseFile(fset, "b.go", "package p; var X int = 1", parser.AllErrors)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CompareFiles(fileA, fset, fileB, fset, &Options{TypeCheck: true}); err == nil {
+		t.Errorf("CompareFiles() returned no error for a package which does not type-check")
+	}
+}
+
+func TestConcurrentComparisons(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		opts *Options
+		want bool
+	}{
+		{
+			a:    "package p; import str \"strings\"; var X = str.ToUpper(\"x\")",
+			b:    "package p; import \"strings\"; var X = strings.ToUpper(\"x\")",
+			opts: &Options{TypeCheck: true},
+			want: true,
+		},
+		{
+			a:    "package a; type T int; var X a.T",
+			b:    "package b; type T int; var X b.T",
+			want: true,
+		},
+		{
+			a:    "package p; var X = 1",
+			b:    "package p; var X = 2",
+			want: false,
+		},
+	}
+
+	errs := make(chan error, 8*len(testCases))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		for _, c := range testCases {
+			wg.Add(1)
+			go func(a string, b string, opts *Options, want bool) {
+				defer wg.Done()
+
+				r, err := CompareSources(map[string][]byte{"a.go": []byte(a)}, map[string][]byte{"b.go": []byte(b)}, opts)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if r.Equivalent != want {
+					errs <- fmt.Errorf("comparing %q with %q: Equivalent = %t, want %t", a, b, r.Equivalent, want)
+				}
+			}(c.a, c.b, c.opts, c.want)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
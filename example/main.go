@@ -2,14 +2,12 @@ package main
 
 import (
 	"fmt"
-	"go/ast"
 	"go/parser"
 	"go/token"
-	"io/ioutil"
 	"path/filepath"
-	"strings"
 
 	eqgo "github.com/kevinmbeaulieu/eq-go/eq-go"
+	"github.com/kevinmbeaulieu/eq-go/eq-go/loader"
 )
 
 func main() {
@@ -18,10 +16,13 @@ func main() {
 	panicIfError(err)
 	rhsPkgPath, err := filepath.Abs("package-b")
 	panicIfError(err)
-	lhsPkg, lhsFSet := loadPackage("package-a", lhsPkgPath)
-	rhsPkg, rhsFSet := loadPackage("package-b", rhsPkgPath)
-	eq, msg := eqgo.PackagesEquivalent(lhsPkg, lhsFSet, rhsPkg, rhsFSet, nil)
-	fmt.Printf("Packages result: %t\n%s\n\n", eq, msg)
+	lhs, err := loader.Load(lhsPkgPath)
+	panicIfError(err)
+	rhs, err := loader.Load(rhsPkgPath)
+	panicIfError(err)
+	result, err := eqgo.ComparePackageFiles(lhs.Files, lhs.Fset, rhs.Files, rhs.Fset, nil)
+	panicIfError(err)
+	fmt.Printf("Packages result: %t\n%s\n\n", result.Equivalent, result.Format(nil))
 
 	// Compare two files
 	fset := token.NewFileSet()
@@ -33,7 +34,7 @@ func main() {
 	panicIfError(err)
 	rhsFile, err := parser.ParseFile(fset, rhsFilePath, nil, parser.AllErrors)
 	panicIfError(err)
-	eq, msg = eqgo.FilesEquivalent(lhsFile, fset, rhsFile, fset, nil)
+	eq, msg := eqgo.FilesEquivalent(lhsFile, fset, rhsFile, fset, nil)
 	fmt.Printf("Files result: %t\n%s\n\n", eq, msg)
 }
 
@@ -44,26 +45,3 @@ func panicIfError(err error) {
 
 	panic(err)
 }
-
-func loadPackage(name string, path string) (*ast.Package, *token.FileSet) {
-	pkg := ast.Package{
-		Name:  name,
-		Files: make(map[string]*ast.File),
-	}
-
-	files, err := ioutil.ReadDir(path)
-	panicIfError(err)
-	fset := token.NewFileSet()
-	for _, f := range files {
-		if !strings.HasSuffix(f.Name(), ".go") {
-			continue
-		}
-
-		filename := filepath.Join(path, f.Name())
-		src, err := parser.ParseFile(fset, filename, nil, parser.AllErrors)
-		panicIfError(err)
-		pkg.Files[filename] = src
-	}
-
-	return &pkg, fset
-}